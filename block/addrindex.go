@@ -0,0 +1,151 @@
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log"
+
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+)
+
+// addrIndexBucket maps pubkey hash || 8-byte height || 4-byte position,
+// all big-endian, to the id of a transaction on the active chain that
+// funds or spends from that pubkey hash, followed by a flags byte. Keys
+// sort by address and then by chain order, which is what history pages
+// walk. Like txindex it is optional and kept up to date while the bucket
+// exists.
+const addrIndexBucket = "addrindex"
+
+const (
+	addrFunding  = 1 << iota // the transaction pays the address
+	addrSpending             // the transaction spends an output of the address
+)
+
+// ErrNoAddrIndex is returned for address queries while the index is off
+var ErrNoAddrIndex = errors.New("address index is not enabled")
+
+// AddressTx is one transaction in the history of an address
+type AddressTx struct {
+	TxID        []byte
+	BlockHeight int64
+	Position    int  // index of the transaction in its block
+	Funding     bool // pays the address
+	Spending    bool // spends an output of the address
+}
+
+func addrIndexKey(pubKeyHash []byte, height int64, position int) []byte {
+	key := make([]byte, len(pubKeyHash)+12)
+	n := copy(key, pubKeyHash)
+	binary.BigEndian.PutUint64(key[n:], uint64(height))
+	binary.BigEndian.PutUint32(key[n+8:], uint32(position))
+	return key
+}
+
+// touchedAddresses returns the pubkey hashes a transaction pays or spends
+// from, with how it touches each
+func touchedAddresses(t *tx.Transaction) map[string]byte {
+	touched := make(map[string]byte)
+	for _, out := range t.Vout {
+		touched[string(out.PubKeyHash)] |= addrFunding
+	}
+	if !t.IsCoinbase() {
+		for _, in := range t.Vin {
+			if len(in.PubKey) == 0 {
+				continue
+			}
+			touched[string(wallet.PubKeyHash(in.PubKey))] |= addrSpending
+		}
+	}
+	return touched
+}
+
+// indexAddresses adds the transactions of a connected block at height, if
+// the index is enabled
+func indexAddresses(txn StoreTx, b *Block, height int64) error {
+	idx := txn.Bucket(addrIndexBucket)
+	if idx == nil {
+		return nil
+	}
+	for i, t := range b.Transactions {
+		for pkh, flags := range touchedAddresses(t) {
+			value := append(append([]byte(nil), t.ID...), flags)
+			if err := idx.Put(addrIndexKey([]byte(pkh), height, i), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unindexAddresses removes the transactions of a disconnected block
+func unindexAddresses(txn StoreTx, b *Block, height int64) error {
+	idx := txn.Bucket(addrIndexBucket)
+	if idx == nil {
+		return nil
+	}
+	for i, t := range b.Transactions {
+		for pkh := range touchedAddresses(t) {
+			if err := idx.Delete(addrIndexKey([]byte(pkh), height, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HasAddrIndex reports whether the address index is enabled
+func (bc *Blockchain) HasAddrIndex() bool {
+	enabled := false
+	err := bc.store.View(func(txn StoreTx) error {
+		enabled = txn.Bucket(addrIndexBucket) != nil
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return enabled
+}
+
+// SetAddrIndex turns the address index on, building it from the active
+// chain, or off, dropping it. The setting is stored in the database.
+func (bc *Blockchain) SetAddrIndex(enabled bool) error {
+	return bc.setOptionalIndex(addrIndexBucket, enabled, indexAddresses)
+}
+
+// AddressHistory returns up to count transactions that funded or spent from
+// pubKeyHash, oldest first, after skipping the first skip of them. total is
+// the length of the whole history.
+func (bc *Blockchain) AddressHistory(pubKeyHash []byte, skip, count int) (history []AddressTx, total int, err error) {
+	err = bc.store.View(func(txn StoreTx) error {
+		idx := txn.Bucket(addrIndexBucket)
+		if idx == nil {
+			return ErrNoAddrIndex
+		}
+
+		c := idx.Cursor()
+		for k, v := c.Seek(pubKeyHash); k != nil && bytes.HasPrefix(k, pubKeyHash); k, v = c.Next() {
+			// a longer pubkey hash can share the prefix
+			if len(k) != len(pubKeyHash)+12 {
+				continue
+			}
+			total++
+			if total <= skip || len(history) >= count {
+				continue
+			}
+
+			n := len(pubKeyHash)
+			flags := v[len(v)-1]
+			history = append(history, AddressTx{
+				TxID:        append([]byte(nil), v[:len(v)-1]...),
+				BlockHeight: int64(binary.BigEndian.Uint64(k[n : n+8])),
+				Position:    int(binary.BigEndian.Uint32(k[n+8:])),
+				Funding:     flags&addrFunding != 0,
+				Spending:    flags&addrSpending != 0,
+			})
+		}
+		return nil
+	})
+	return history, total, err
+}
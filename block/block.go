@@ -2,34 +2,95 @@ package block
 
 import (
     "bytes"
+    "context"
     "crypto/sha256"
-    "encoding/gob"
+    "encoding/hex"
+    "errors"
+    "fmt"
     "log"
     "strconv"
+    "strings"
     "time"
 
+    "github.com/Shubham0699/go-mini-blockchain/chaincfg"
+    "github.com/Shubham0699/go-mini-blockchain/merkle"
     "github.com/Shubham0699/go-mini-blockchain/proof"
     "github.com/Shubham0699/go-mini-blockchain/tx"
+    "github.com/Shubham0699/go-mini-blockchain/wire"
 )
 
 type Block struct {
+    Version       uint32
     Timestamp     int64
     Data          []byte
     PrevBlockHash []byte
     Hash          []byte
     Nonce         int64
+    Bits          uint32
+    MerkleRoot    []byte
     Transactions  []*tx.Transaction
+    Height        int64 // position in the chain, genesis is 0; not part of the header
+
+    // Proof-of-authority seal; empty on proof-of-work chains
+    Signer    []byte // public key of the sealing signer
+    Signature []byte // signature over the hash and the fields below
+    Vote      []byte // address the signer votes on, if any
+    VoteAuth  bool   // true to authorise Vote, false to drop it
 }
 
-func init() {
-    gob.Register(&tx.Transaction{})
+// ErrTxNotFound is returned when a transaction is not part of a block
+var ErrTxNotFound = errors.New("transaction not found in block")
+
+// Implementing proof.BlockData interface (SerializeHeader is in header.go)
+func (b *Block) PrevHash() []byte     { return b.PrevBlockHash }
+func (b *Block) TimestampUnix() int64 { return b.Timestamp }
+func (b *Block) NonceValue() int64    { return b.Nonce }
+func (b *Block) BitsValue() uint32    { return b.Bits }
+
+// Implementing consensus.Header interface
+func (b *Block) HashBytes() []byte                { return b.Hash }
+func (b *Block) SetBits(bits uint32)              { b.Bits = bits }
+func (b *Block) SetTimestamp(ts int64)            { b.Timestamp = ts }
+func (b *Block) SetSeal(nonce int64, hash []byte) { b.Nonce, b.Hash = nonce, hash }
+func (b *Block) SignerKey() []byte                { return b.Signer }
+func (b *Block) SignatureBytes() []byte           { return b.Signature }
+func (b *Block) SetSignature(signer, sig []byte)  { b.Signer, b.Signature = signer, sig }
+func (b *Block) VoteData() ([]byte, bool)         { return b.Vote, b.VoteAuth }
+func (b *Block) SetVote(target []byte, auth bool) { b.Vote, b.VoteAuth = target, auth }
+
+// merkleLeaves returns the data committed by the Merkle root: the hash of
+// every transaction, or the raw Data payload for legacy string blocks.
+func (b *Block) merkleLeaves() [][]byte {
+    if len(b.Transactions) == 0 {
+        return [][]byte{b.Data}
+    }
+    leaves := make([][]byte, len(b.Transactions))
+    for i, t := range b.Transactions {
+        leaves[i] = t.Hash()
+    }
+    return leaves
 }
 
-// Implementing proof.BlockData interface
-func (b *Block) PrevHash() []byte        { return b.PrevBlockHash }
-func (b *Block) DataBytes() []byte       { return b.Data }
-func (b *Block) TimestampUnix() int64    { return b.Timestamp }
-func (b *Block) NonceValue() int64       { return b.Nonce }
+// HashTransactions computes the Merkle root over the block contents
+func (b *Block) HashTransactions() []byte {
+    return merkle.Root(b.merkleLeaves())
+}
+
+// HasValidMerkleRoot reports whether MerkleRoot matches the block contents
+func (b *Block) HasValidMerkleRoot() bool {
+    return bytes.Equal(b.MerkleRoot, b.HashTransactions())
+}
+
+// TxProof builds a Merkle inclusion proof for the transaction with txID.
+// Verify it with proof.Verify(b.MerkleRoot, t.Hash()).
+func (b *Block) TxProof(txID []byte) (*merkle.Proof, error) {
+    for i, t := range b.Transactions {
+        if bytes.Equal(t.ID, txID) {
+            return merkle.NewTree(b.merkleLeaves()).Proof(i)
+        }
+    }
+    return nil, ErrTxNotFound
+}
 
 // Legacy SetHash (not used with PoW)
 func (b *Block) SetHash() {
@@ -46,70 +107,221 @@ func (b *Block) SetHash() {
 }
 
 // ✅ NewBlock for simple string data blocks
-func NewBlock(data string, prevBlockHash []byte) *Block {
+func NewBlock(data string, prevBlockHash []byte, bits uint32) *Block {
+    block, err := NewBlockContext(context.Background(), data, prevBlockHash, bits)
+    if err != nil {
+        log.Panic(err)
+    }
+    return block
+}
+
+// NewBlockContext is NewBlock with a context that can abort mining
+func NewBlockContext(ctx context.Context, data string, prevBlockHash []byte, bits uint32) (*Block, error) {
+    block := newDataBlock(data, prevBlockHash, bits)
+    if _, err := block.Mine(ctx, 0); err != nil {
+        return nil, err
+    }
+    return block, nil
+}
+
+// NewBlockWithTxs creates a block containing transactions
+func NewBlockWithTxs(transactions []*tx.Transaction, prevBlockHash []byte, bits uint32, params *chaincfg.Params) *Block {
+    block, err := NewBlockWithTxsContext(context.Background(), transactions, prevBlockHash, bits, params)
+    if err != nil {
+        log.Panic(err)
+    }
+    return block
+}
+
+// NewBlockWithTxsContext is NewBlockWithTxs with a context that can abort
+// mining. Blocks over the limits of params, the rules of the chain the
+// block is for, are refused.
+func NewBlockWithTxsContext(ctx context.Context, transactions []*tx.Transaction, prevBlockHash []byte, bits uint32, params *chaincfg.Params) (*Block, error) {
+    block := newTxBlock(transactions, prevBlockHash, bits)
+    if err := checkBlockLimits(block, params); err != nil {
+        return nil, err
+    }
+    if _, err := block.Mine(ctx, 0); err != nil {
+        return nil, err
+    }
+    return block, nil
+}
+
+// newDataBlock assembles an unmined string data block
+func newDataBlock(data string, prevBlockHash []byte, bits uint32) *Block {
     block := &Block{
+        Version:       BlockVersion,
         Timestamp:     time.Now().Unix(),
         Data:          []byte(data),
         PrevBlockHash: prevBlockHash,
         Hash:          []byte{},
         Nonce:         0,
+        Bits:          bits,
         Transactions:  nil,
+        Height:        0,
     }
-
-    pow := proof.NewProofOfWork(block)
-    nonce, hash := pow.Run()
-    block.Hash = hash
-    block.Nonce = nonce
-
+    block.MerkleRoot = block.HashTransactions()
     return block
 }
 
-// NewBlockWithTxs creates a block containing transactions
-func NewBlockWithTxs(transactions []*tx.Transaction, prevBlockHash []byte) *Block {
+// newTxBlock assembles an unmined block of transactions
+func newTxBlock(transactions []*tx.Transaction, prevBlockHash []byte, bits uint32) *Block {
     block := &Block{
+        Version:       BlockVersion,
         Timestamp:     time.Now().Unix(),
         Data:          nil,
         PrevBlockHash: prevBlockHash,
         Hash:          []byte{},
         Nonce:         0,
+        Bits:          bits,
         Transactions:  transactions,
     }
+    block.MerkleRoot = block.HashTransactions()
+    return block
+}
 
-    pow := proof.NewProofOfWork(block)
-    nonce, hash := pow.Run()
-    block.Hash = hash
-    block.Nonce = nonce
+// Mine runs proof of work on workers goroutines (0 = one per CPU) and fills
+// in Nonce and Hash
+func (b *Block) Mine(ctx context.Context, workers int) (proof.MiningStats, error) {
+    pow := proof.NewProofOfWork(b)
+    pow.Workers = workers
 
-    return block
+    nonce, hash, err := pow.Run(ctx)
+    if err != nil {
+        return pow.Stats, err
+    }
+    b.Hash = hash
+    b.Nonce = nonce
+    return pow.Stats, nil
 }
 
-// Genesis block
-func NewGenesisBlock() *Block {
-    return NewBlock("Genesis Block", []byte{})
+// RollNonceSpace implements proof.NonceSpaceRoller. Blocks with a coinbase
+// bump its extra-nonce, which changes the merkle root; others bump the
+// timestamp.
+func (b *Block) RollNonceSpace() {
+    if len(b.Transactions) > 0 {
+        cb := b.Transactions[0]
+        if height, err := cb.CoinbaseHeight(); err == nil {
+            // copy the slice so the caller's transactions stay untouched
+            b.Transactions = append([]*tx.Transaction(nil), b.Transactions...)
+            b.Transactions[0] = cb.WithCoinbaseScript(height, cb.CoinbaseExtraNonce()+1)
+            b.MerkleRoot = b.HashTransactions()
+            return
+        }
+    }
+    b.Timestamp++
 }
 
-// Serialize block to bytes
+// Genesis block. Proof-of-authority chains commit to their initial signer
+// set here, so every signer set gets its own genesis hash.
+func NewGenesisBlock(params *chaincfg.Params) *Block {
+    return NewBlock(genesisData(params), []byte{}, params.GenesisBits)
+}
+
+// genesisData is the payload of the genesis block of params
+func genesisData(params *chaincfg.Params) string {
+    data := "Genesis Block"
+    if params.Consensus == chaincfg.ProofOfAuthority {
+        data += " (poa: " + strings.Join(params.Signers, ",") + ")"
+    }
+    return data
+}
+
+// EncodingVersion is the version of the block format below. Decoding
+// rejects any other version.
+//
+// A block is laid out as (see package wire for the field types):
+//
+//    uint32   version (1)
+//    uint32   Version
+//    int64    Timestamp
+//    bytes    PrevBlockHash
+//    bytes    Hash
+//    int64    Nonce
+//    uint32   Bits
+//    bytes    MerkleRoot
+//    int64    Height
+//    bytes    Data
+//    bytes    Signer
+//    bytes    Signature
+//    bytes    Vote
+//    bool     VoteAuth
+//    uint32   transaction count, then each transaction (see tx.EncodingVersion)
+const EncodingVersion = 1
+
+// minTxSize is the smallest encoded transaction: version, ID and two counts
+const minTxSize = 4 * 4
+
+// Serialize returns the binary encoding of the block, used on disk and
+// between peers
 func (b *Block) Serialize() []byte {
-    var result bytes.Buffer
-    encoder := gob.NewEncoder(&result)
+    var w wire.Writer
+    w.PutUint32(EncodingVersion)
+    w.PutUint32(b.Version)
+    w.PutInt64(b.Timestamp)
+    w.PutBytes(b.PrevBlockHash)
+    w.PutBytes(b.Hash)
+    w.PutInt64(b.Nonce)
+    w.PutUint32(b.Bits)
+    w.PutBytes(b.MerkleRoot)
+    w.PutInt64(b.Height)
+    w.PutBytes(b.Data)
+    w.PutBytes(b.Signer)
+    w.PutBytes(b.Signature)
+    w.PutBytes(b.Vote)
+    w.PutBool(b.VoteAuth)
+    w.PutUint32(uint32(len(b.Transactions)))
+    for _, t := range b.Transactions {
+        t.Encode(&w)
+    }
+    return w.Bytes()
+}
 
-    err := encoder.Encode(b)
-    if err != nil {
-        log.Panic(err)
+// Deserialize decodes a block produced by Serialize
+func Deserialize(d []byte) (*Block, error) {
+    r := wire.NewReader(d)
+    if v := r.Uint32(); r.Err() == nil && v != EncodingVersion {
+        return nil, fmt.Errorf("decoding block: unsupported encoding version %d", v)
     }
 
-    return result.Bytes()
+    b := &Block{
+        Version:       r.Uint32(),
+        Timestamp:     r.Int64(),
+        PrevBlockHash: r.Bytes(),
+        Hash:          r.Bytes(),
+        Nonce:         r.Int64(),
+        Bits:          r.Uint32(),
+        MerkleRoot:    r.Bytes(),
+        Height:        r.Int64(),
+        Data:          r.Bytes(),
+        Signer:        r.Bytes(),
+        Signature:     r.Bytes(),
+        Vote:          r.Bytes(),
+        VoteAuth:      r.Bool(),
+    }
+    n := r.Count(minTxSize)
+    for i := 0; i < n && r.Err() == nil; i++ {
+        t := &tx.Transaction{}
+        t.Decode(r)
+        b.Transactions = append(b.Transactions, t)
+    }
+
+    if err := r.Done(); err != nil {
+        return nil, fmt.Errorf("decoding block: %w", err)
+    }
+    return b, nil
 }
 
-// Deserialize bytes to block
-func Deserialize(d []byte) *Block {
-    var block Block
+// Hex returns the serialized block as hex
+func (b *Block) Hex() string {
+    return hex.EncodeToString(b.Serialize())
+}
 
-    decoder := gob.NewDecoder(bytes.NewReader(d))
-    err := decoder.Decode(&block)
+// BlockFromHex decodes a block from the output of Hex
+func BlockFromHex(s string) (*Block, error) {
+    d, err := hex.DecodeString(s)
     if err != nil {
-        log.Panic(err)
+        return nil, fmt.Errorf("decoding block: %w", err)
     }
-
-    return &block
+    return Deserialize(d)
 }
@@ -0,0 +1,114 @@
+package block
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+// goldenBlock and its encoding pin the layout documented on EncodingVersion;
+// the bytes were produced independently of this package. The fields are
+// short on purpose: only the encoding is under test, not the block's rules.
+func goldenBlock() *Block {
+	t := &tx.Transaction{
+		Vin:  []tx.TXInput{{Txid: []byte{1, 2}, Vout: 3, Signature: []byte{4}, PubKey: []byte{5, 6}}},
+		Vout: []tx.TXOutput{{Value: 50, PubKeyHash: []byte{8, 9}}},
+	}
+	t.SetID()
+	return &Block{
+		Version:       1,
+		Timestamp:     1700000000,
+		PrevBlockHash: []byte{0x11, 0x11},
+		Hash:          []byte{0x33, 0x33},
+		Nonce:         7,
+		Bits:          16,
+		MerkleRoot:    []byte{0x22, 0x22},
+		Height:        5,
+		Data:          []byte("hi"),
+		Transactions:  []*tx.Transaction{t},
+	}
+}
+
+const goldenBlockHex = "01000000" + // encoding version
+	"01000000" + // Version
+	"00f1536500000000" + // Timestamp
+	"020000001111" + // PrevBlockHash
+	"020000003333" + // Hash
+	"0700000000000000" + // Nonce
+	"10000000" + // Bits
+	"020000002222" + // MerkleRoot
+	"0500000000000000" + // Height
+	"020000006869" + // Data
+	"00000000" + "00000000" + "00000000" + // Signer, Signature, Vote
+	"00" + // VoteAuth
+	"01000000" + // transactions
+	"01000000" +
+	"20000000306e79f9f0f2a713f7971eb6acc0586dda6876e460b7f468948921c42efc822b" +
+	"01000000" + "020000000102" + "03000000" + "0100000004" + "020000000506" +
+	"01000000" + "3200000000000000" + "020000000809"
+
+func TestBlockSerializeGolden(t *testing.T) {
+	if got := goldenBlock().Hex(); got != goldenBlockHex {
+		t.Errorf("serialized block\n got %s\nwant %s", got, goldenBlockHex)
+	}
+}
+
+func TestBlockRoundTrip(t *testing.T) {
+	poa := goldenBlock()
+	poa.Signer = []byte{0xaa}
+	poa.Signature = []byte{0xbb, 0xcc}
+	poa.Vote = []byte{0xdd}
+	poa.VoteAuth = true
+	poa.Transactions = append(poa.Transactions, tx.NewCoinbaseTX("00112233445566778899aabbccddeeff00112233", 50, 5, 0))
+	poa.Transactions[1].Vin[0].Txid = nil // decoding gives nil for empty byte strings
+
+	empty := goldenBlock()
+	empty.Transactions = nil
+
+	for _, want := range []*Block{goldenBlock(), poa, empty} {
+		got, err := BlockFromHex(want.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("decoded %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestDeserializeRejectsMalformed(t *testing.T) {
+	raw, _ := hex.DecodeString(goldenBlockHex)
+	for n := 0; n < len(raw); n++ {
+		if _, err := Deserialize(raw[:n]); err == nil {
+			t.Errorf("truncated to %d bytes: no error", n)
+		}
+	}
+
+	if _, err := Deserialize(append(raw[:len(raw):len(raw)], 0)); err == nil {
+		t.Error("trailing byte: no error")
+	}
+
+	bad := append([]byte(nil), raw...)
+	bad[0] = 2
+	if _, err := Deserialize(bad); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("unsupported version: got %v", err)
+	}
+
+	// the transaction count and VoteAuth sit just before the transactions
+	count := len(raw) - len(goldenBlock().Transactions[0].Serialize()) - 4
+
+	huge := append([]byte(nil), raw[:count+4]...)
+	copy(huge[count:], []byte{0xff, 0xff, 0xff, 0xff})
+	if _, err := Deserialize(huge); err == nil {
+		t.Error("oversized transaction count: no error")
+	}
+
+	vote := append([]byte(nil), raw...)
+	vote[count-1] = 2 // a bool may only be 0 or 1
+	if _, err := Deserialize(vote); err == nil {
+		t.Error("invalid VoteAuth byte: no error")
+	}
+}
@@ -1,148 +1,542 @@
 package block
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"log"
+	"math/big"
+	"os"
+	"path/filepath"
 	"sync"
 
-	"github.com/boltdb/bolt"
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/consensus"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
 	"github.com/Shubham0699/go-mini-blockchain/tx"
 )
 
 const (
-	dbFile       = "blockchain.db"
-	blocksBucket = "blocks"
-	lastHashKey  = "lh"
+	dbFile      = "blockchain.db"
+	dataDirName = ".go-mini-blockchain"
 )
 
-// Blockchain represents the chain stored in BoltDB
+// ErrStaleTip is returned when the tip moved while a block was being mined
+var ErrStaleTip = errors.New("chain tip changed while mining")
+
+// Blockchain represents the chain kept in a ChainStore
 type Blockchain struct {
-	tip []byte   // last block hash
-	db  *bolt.DB // BoltDB instance
+	tip    []byte           // last block hash
+	store  ChainStore       // blocks and indexes
+	params *chaincfg.Params // consensus rules
+	engine consensus.Engine // seals and verifies blocks
+
+	timeSource MedianTimeSource // network-adjusted clock
+	headers    headerChain      // header chain known ahead of the blocks, if any
+
+	mu         sync.RWMutex  // guards tip, tipChanged, prune and headers
+	prune      PruneTarget   // history to keep; pruning is off when zero
+	tipChanged chan struct{} // closed and replaced every time the tip moves
+}
+
+// DefaultDataDir is where chains are stored when no data directory is given
+func DefaultDataDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dataDirName
+	}
+	return filepath.Join(home, dataDirName)
 }
 
-// CreateBlockchain creates a new blockchain with a genesis block
-func CreateBlockchain() *Blockchain {
-	var tip []byte
+// DataPath returns the database file of the network params inside dataDir.
+// Every network gets its own subdirectory, so their chains never mix.
+func DataPath(dataDir string, params *chaincfg.Params) string {
+	return filepath.Join(dataDir, params.Name, dbFile)
+}
 
-	db, err := bolt.Open(dbFile, 0600, nil)
+// WarnLegacyDatabase logs a warning while ./blockchain.db exists: nodes
+// kept their chain there before data directories, and opening the chain at
+// path does not pick it up. The old file could belong to any network, and
+// if it holds blocks from before versioned block headers it cannot be
+// opened at all (ErrLegacyBlocks), so it is left for the user to deal with.
+func WarnLegacyDatabase(path string) {
+	legacy, err := filepath.Abs(dbFile)
 	if err != nil {
-		log.Panic(err)
+		return
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return
 	}
+	log.Printf("⚠️ %s is a chain from before data directories and is not used; chains are kept at %s now. "+
+		"Chains from before versioned block headers cannot be migrated and have to be synced again", legacy, path)
+}
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(blocksBucket))
+// Open opens the chain stored in the database file at path, creating it
+// (and its directory) with a genesis block for params if it does not exist.
+// Every call returns an independent *Blockchain; a database file can only
+// be open in one of them at a time.
+func Open(path string, params *chaincfg.Params) (*Blockchain, error) {
+	return OpenWithGenesis(path, params, nil)
+}
 
-		if b == nil {
-			// No existing chain → create one
-			genesis := NewGenesisBlock()
+// OpenWithGenesis is Open with the genesis block a new database starts
+// from (see NewWithGenesis)
+func OpenWithGenesis(path string, params *chaincfg.Params, genesis *Block) (*Blockchain, error) {
+	store, err := OpenBoltStore(path)
+	if err != nil {
+		return nil, err
+	}
+	bc, err := NewWithGenesis(store, params, genesis)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return bc, nil
+}
 
-			b, err := tx.CreateBucket([]byte(blocksBucket))
-			if err != nil {
-				log.Panic(err)
-			}
+// New loads the chain kept in store, writing a genesis block for params
+// first if the store is empty. Stores written by older versions are
+// migrated first (see SchemaVersion). NewMemStore gives a chain that lives
+// only in memory.
+func New(store ChainStore, params *chaincfg.Params) (*Blockchain, error) {
+	return NewWithGenesis(store, params, nil)
+}
 
-			// Serialize genesis block
-			err = b.Put(genesis.Hash, genesis.Serialize())
-			if err != nil {
-				log.Panic(err)
-			}
+// NewWithGenesis is New with the genesis block an empty store starts from,
+// e.g. the one of a bootstrap file, instead of a freshly mined one. It is
+// ignored when the store already holds a chain. A nil genesis mines one.
+func NewWithGenesis(store ChainStore, params *chaincfg.Params, genesis *Block) (*Blockchain, error) {
+	bc := &Blockchain{
+		store:      store,
+		params:     params,
+		engine:     consensus.New(params),
+		timeSource: NewMedianTime(),
+		tipChanged: make(chan struct{}),
+	}
 
-			// Save last hash
-			err = b.Put([]byte(lastHashKey), genesis.Hash)
-			if err != nil {
-				log.Panic(err)
-			}
+	if err := bc.upgradeSchema(); err != nil {
+		return nil, err
+	}
 
-			tip = genesis.Hash
-		} else {
+	err := store.Update(func(txn StoreTx) error {
+		if tip := txn.Tip(); tip != nil {
 			// Chain exists → load last hash
-			tip = b.Get([]byte(lastHashKey))
+			bc.tip = append([]byte(nil), tip...)
+			return nil
 		}
 
+		// No existing chain → create one
+		if genesis == nil {
+			genesis = NewGenesisBlock(params)
+		} else if err := checkGenesis(genesis, params); err != nil {
+			return err
+		}
+		if err := txn.PutBlock(genesis); err != nil {
+			return err
+		}
+		if err := txn.SetTip(genesis.Hash); err != nil {
+			return err
+		}
+
+		// Genesis starts the block index at height 0
+		idx, err := txn.CreateBucket(blockIndexBucket)
+		if err != nil {
+			return err
+		}
+		entry := blockIndexEntry{Height: 0, ChainWork: bc.engine.Work(genesis)}
+		if err := idx.Put(genesis.Hash, entry.serialize()); err != nil {
+			return err
+		}
+
+		// and the chain state (an empty UTXO set)
+		if err := buildChainState(txn); err != nil {
+			return err
+		}
+		if err := writeSchema(txn, SchemaVersion, params.Name); err != nil {
+			return err
+		}
+
+		bc.tip = genesis.Hash
 		return nil
 	})
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
+	return bc, nil
+}
 
-	return &Blockchain{tip, db}
+// Params returns the consensus rules of the chain
+func (bc *Blockchain) Params() *chaincfg.Params {
+	return bc.params
 }
 
-// AddBlock saves a new block into BoltDB (string data payload)
-func (bc *Blockchain) AddBlock(data string) {
-	var lastHash []byte
+// Engine returns the consensus engine chosen at genesis
+func (bc *Blockchain) Engine() consensus.Engine {
+	return bc.engine
+}
 
-	err := bc.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(blocksBucket))
-		lastHash = b.Get([]byte(lastHashKey))
-		return nil
-	})
-	if err != nil {
-		log.Panic(err)
+// TimeSource returns the network-adjusted clock; peers feed it samples
+func (bc *Blockchain) TimeSource() MedianTimeSource {
+	return bc.timeSource
+}
+
+// GetHeader implements consensus.ChainReader
+func (bc *Blockchain) GetHeader(hash []byte) consensus.Header {
+	if b := bc.getBlock(hash); b != nil {
+		return b
 	}
+	return nil
+}
 
-	newBlock := NewBlock(data, lastHash)
+// Height implements consensus.ChainReader
+func (bc *Blockchain) Height(hash []byte) int64 {
+	return bc.blockHeight(hash)
+}
+
+// Tip returns the hash of the current chain tip
+func (bc *Blockchain) Tip() []byte {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.tip
+}
 
-	err = bc.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(blocksBucket))
+// TipChanged returns a channel that is closed the next time the tip moves
+func (bc *Blockchain) TipChanged() <-chan struct{} {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.tipChanged
+}
 
-		err := b.Put(newBlock.Hash, newBlock.Serialize())
+// AddBlock mines a new block onto the chain (string data payload)
+func (bc *Blockchain) AddBlock(data string) {
+	for {
+		_, err := bc.AddBlockContext(context.Background(), data)
+		if err == ErrStaleTip {
+			continue // someone else moved the tip, mine on the new one
+		}
 		if err != nil {
 			log.Panic(err)
 		}
+		return
+	}
+}
 
-		err = b.Put([]byte(lastHashKey), newBlock.Hash)
+// AddBlockContext mines a string data block on the current tip. Mining is
+// aborted with ctx.Err() when ctx is cancelled, or with ErrStaleTip when
+// another block becomes the tip first.
+func (bc *Blockchain) AddBlockContext(ctx context.Context, data string) (*Block, error) {
+	return bc.mineOnTip(ctx, func(parent *Block) *Block {
+		return newDataBlock(data, parent.Hash, 0)
+	})
+}
+
+// MineBlock mines a new block containing real transactions
+func (bc *Blockchain) MineBlock(transactions []*tx.Transaction) {
+	for {
+		_, err := bc.MineBlockContext(context.Background(), transactions)
+		if err == ErrStaleTip {
+			continue
+		}
 		if err != nil {
 			log.Panic(err)
 		}
+		return
+	}
+}
 
-		bc.tip = newBlock.Hash
-		return nil
+// MineBlockContext mines a block of transactions on the current tip, with
+// the same cancellation rules as AddBlockContext. A coinbase built for
+// another height (e.g. before the tip moved) is recommitted to the height
+// the block is mined at.
+func (bc *Blockchain) MineBlockContext(ctx context.Context, transactions []*tx.Transaction) (*Block, error) {
+	return bc.mineOnTip(ctx, func(parent *Block) *Block {
+		txs := commitCoinbaseHeight(transactions, bc.blockHeight(parent.Hash)+1)
+		return newTxBlock(txs, parent.Hash, 0)
 	})
-	if err != nil {
-		log.Panic(err)
+}
+
+// commitCoinbaseHeight returns txs with the leading coinbase committing to
+// height. txs itself is not modified.
+func commitCoinbaseHeight(txs []*tx.Transaction, height int64) []*tx.Transaction {
+	if len(txs) == 0 || !txs[0].IsCoinbase() {
+		return txs
 	}
+	if committed, err := txs[0].CoinbaseHeight(); err == nil && committed == height {
+		return txs
+	}
+	out := append([]*tx.Transaction(nil), txs...)
+	out[0] = txs[0].WithCoinbaseScript(height, txs[0].CoinbaseExtraNonce())
+	return out
 }
 
-// MineBlock mines a new block containing real transactions
-func (bc *Blockchain) MineBlock(transactions []*tx.Transaction) {
-	newBlock := NewBlockWithTxs(transactions, bc.tip)
+// SetMiningWorkers sets how many goroutines search for nonces (0 = one per
+// CPU). It has no effect on proof-of-authority chains.
+func (bc *Blockchain) SetMiningWorkers(n int) {
+	if pow, ok := bc.engine.(*consensus.PoW); ok {
+		pow.SetWorkers(n)
+	}
+}
 
-	err := bc.db.Update(func(txn *bolt.Tx) error {
-		b := txn.Bucket([]byte(blocksBucket))
+// LastMiningStats returns hashrate and timing for the last mined block
+func (bc *Blockchain) LastMiningStats() proof.MiningStats {
+	if pow, ok := bc.engine.(*consensus.PoW); ok {
+		return pow.LastStats()
+	}
+	return proof.MiningStats{}
+}
 
-		if err := b.Put(newBlock.Hash, newBlock.Serialize()); err != nil {
+// mineOnTip prepares and seals the block assembled on the current tip, then
+// stores it. Sealing is cancelled as soon as the tip changes.
+func (bc *Blockchain) mineOnTip(ctx context.Context, assemble func(parent *Block) *Block) (*Block, error) {
+	bc.mu.RLock()
+	tip, tipChanged := bc.tip, bc.tipChanged
+	bc.mu.RUnlock()
+
+	mineCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-tipChanged:
+			cancel()
+		case <-mineCtx.Done():
+		}
+	}()
+
+	parent := bc.getBlock(tip)
+	newBlock := assemble(parent)
+	newBlock.Height = bc.blockHeight(parent.Hash) + 1
+	if err := checkBlockLimits(newBlock, bc.params); err != nil {
+		return nil, err
+	}
+	if mtp := bc.medianTimePast(parent); newBlock.Timestamp <= mtp {
+		newBlock.Timestamp = mtp + 1 // our clock is behind the chain
+	}
+	if err := bc.engine.Prepare(bc, newBlock); err != nil {
+		return nil, err
+	}
+	if err := bc.checkTransactions(newBlock, bc.newTxLookup(parent), true); err != nil {
+		return nil, err
+	}
+	if err := bc.engine.Seal(mineCtx, bc, newBlock); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if mineCtx.Err() != nil {
+			return nil, ErrStaleTip
+		}
+		return nil, err
+	}
+
+	if err := bc.checkCheckpoint(newBlock, newBlock.Height); err != nil {
+		return nil, err
+	}
+	if _, err := bc.storeBlock(newBlock, true); err != nil {
+		return nil, err
+	}
+	return newBlock, nil
+}
+
+// AcceptBlock validates a block received from elsewhere (e.g. a peer) and
+// stores it. Blocks on a side branch are kept, and the branch becomes the
+// active chain once it has more cumulative work than the current one. A
+// tip change cancels any mining still running on the old tip. Rule
+// violations come back as a RuleError.
+func (bc *Blockchain) AcceptBlock(b *Block) error {
+	if bc.getBlock(b.Hash) != nil {
+		return ruleError(ErrDuplicateBlock, "already have block %x", b.Hash)
+	}
+
+	parent := bc.getBlock(b.PrevBlockHash)
+	if parent == nil {
+		return ruleError(ErrMissingParent, "previous block %x is unknown", b.PrevBlockHash)
+	}
+
+	if err := bc.checkBlock(b, parent); err != nil {
+		return err
+	}
+
+	_, err := bc.storeBlock(b, false)
+	return err
+}
+
+// checkBlock applies the context-dependent consensus rules to b
+func (bc *Blockchain) checkBlock(b *Block, parent *Block) error {
+	if b.Version < 1 || b.Version > BlockVersion {
+		return ruleError(ErrBadVersion, "block version %d is not supported", b.Version)
+	}
+
+	if err := checkBlockLimits(b, bc.params); err != nil {
+		return err
+	}
+
+	height := bc.blockHeight(parent.Hash) + 1
+	if b.Height != height {
+		return ruleError(ErrBadHeight, "block %x claims height %d, expected %d", b.Hash, b.Height, height)
+	}
+	// the header checks come first: they are cheap, and a block with a bad
+	// seal should not cost a chain state switch or signature checks
+	if err := bc.engine.VerifyHeader(bc, b); err != nil {
+		return ruleError(sealErrorCode(err), "block %x: %v", b.Hash, err)
+	}
+	if err := bc.checkCheckpoint(b, height); err != nil {
+		return err
+	}
+
+	if err := bc.checkTimestamp(b, parent); err != nil {
+		return err
+	}
+
+	if !b.HasValidMerkleRoot() {
+		return ruleError(ErrBadMerkleRoot, "block %x has an invalid merkle root", b.Hash)
+	}
+
+	// signatures below the assume-valid block are taken on trust
+	verifySigs := !bc.assumedValid(b, height)
+	if bytes.Equal(parent.Hash, bc.Tip()) {
+		if err := bc.checkTransactions(b, bc.newTxLookup(parent), verifySigs); err != nil {
+			return err
+		}
+	} else if err := bc.checkBranchTransactions(b, parent, verifySigs); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkTimestamp requires the block time to be after the median of the
+// previous blocks and not too far ahead of network-adjusted time
+func (bc *Blockchain) checkTimestamp(b *Block, parent *Block) error {
+	if mtp := bc.medianTimePast(parent); b.Timestamp <= mtp {
+		return ruleError(ErrTimeTooOld,
+			"block timestamp %d is not after the median time past %d", b.Timestamp, mtp)
+	}
+
+	maxTime := bc.timeSource.AdjustedTime().Unix() + bc.params.MaxTimeDrift
+	if b.Timestamp > maxTime {
+		return ruleError(ErrTimeTooNew,
+			"block timestamp %d is more than %d seconds ahead of network time", b.Timestamp, bc.params.MaxTimeDrift)
+	}
+	return nil
+}
+
+// storeBlock writes the block together with its height and cumulative
+// chainwork. It moves the tip to the block when that gives the active chain
+// more work (a reorg if the block is on a side branch) and reports whether
+// it did. With extendTip set it fails with ErrStaleTip unless the block
+// builds directly on the current tip.
+func (bc *Blockchain) storeBlock(newBlock *Block, extendTip bool) (bool, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if extendTip && !bytes.Equal(newBlock.PrevBlockHash, bc.tip) {
+		return false, ErrStaleTip
+	}
+
+	becameTip := false
+	err := bc.store.Update(func(txn StoreTx) error {
+		idx := txn.Bucket(blockIndexBucket)
+
+		parent, ok := readIndexEntry(txn, newBlock.PrevBlockHash)
+		if !ok {
+			return ruleError(ErrMissingParent, "previous block %x is unknown", newBlock.PrevBlockHash)
+		}
+		entry := blockIndexEntry{
+			Height:    parent.Height + 1,
+			ChainWork: new(big.Int).Add(parent.ChainWork, bc.engine.Work(newBlock)),
+		}
+
+		if err := txn.PutBlock(newBlock); err != nil {
+			log.Panic(err)
+		}
+		if err := idx.Put(newBlock.Hash, entry.serialize()); err != nil {
 			log.Panic(err)
 		}
-		if err := b.Put([]byte(lastHashKey), newBlock.Hash); err != nil {
+
+		// ties go to the chain we saw first
+		tip, _ := readIndexEntry(txn, bc.tip)
+		if entry.ChainWork.Cmp(tip.ChainWork) <= 0 {
+			return nil
+		}
+		// the UTXO set follows the tip within the same transaction, so a
+		// block that spends a missing output is not stored either
+		if err := switchChainState(txn, bc.tip, newBlock.Hash); err != nil {
+			return err
+		}
+		if err := txn.SetTip(newBlock.Hash); err != nil {
 			log.Panic(err)
 		}
-		bc.tip = newBlock.Hash
+		becameTip = true
+
+		if bc.prune.Enabled() {
+			_, err := pruneBlocks(txn, bc.prune, entry.Height)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if !becameTip {
+		log.Printf("🌿 Stored block %x on a side branch", newBlock.Hash)
+		return false, nil
+	}
+
+	if !bytes.Equal(newBlock.PrevBlockHash, bc.tip) {
+		log.Printf("🔀 Reorganized: side branch ending at %x now has the most work", newBlock.Hash)
+	}
+	bc.tip = newBlock.Hash
+	close(bc.tipChanged)
+	bc.tipChanged = make(chan struct{})
+	return true, nil
+}
+
+// getBlock loads a block by hash, or returns nil if it is not stored
+func (bc *Blockchain) getBlock(hash []byte) *Block {
+	var block *Block
+
+	err := bc.store.View(func(txn StoreTx) error {
+		block = txn.GetBlock(hash)
 		return nil
 	})
 	if err != nil {
 		log.Panic(err)
 	}
+	return block
+}
+
+// blockHeight counts the blocks between hash and genesis
+func (bc *Blockchain) blockHeight(hash []byte) int64 {
+	if entry, ok := bc.indexEntry(hash); ok {
+		return entry.Height
+	}
+
+	// not indexed yet, count by walking back
+	var height int64
+	it := &BlockchainIterator{hash, bc.store}
+
+	for {
+		b := it.Next()
+		if len(b.PrevBlockHash) == 0 {
+			return height
+		}
+		height++
+	}
 }
 
 // Iterator to traverse blockchain
 type BlockchainIterator struct {
 	currentHash []byte
-	db          *bolt.DB
+	store       ChainStore
 }
 
 func (bc *Blockchain) Iterator() *BlockchainIterator {
-	return &BlockchainIterator{bc.tip, bc.db}
+	return &BlockchainIterator{bc.Tip(), bc.store}
 }
 
 func (it *BlockchainIterator) Next() *Block {
 	var block *Block
 
-	err := it.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(blocksBucket))
-		encodedBlock := b.Get(it.currentHash)
-		block = Deserialize(encodedBlock)
-
+	err := it.store.View(func(txn StoreTx) error {
+		block = txn.GetBlock(it.currentHash)
 		return nil
 	})
 	if err != nil {
@@ -153,30 +547,19 @@ func (it *BlockchainIterator) Next() *Block {
 	return block
 }
 
-// Close closes the underlying BoltDB
+// Close closes the underlying store
 func (bc *Blockchain) Close() {
-	bc.db.Close()
+	bc.store.Close()
 }
 
-var (
-	blockchainInstance *Blockchain
-	once               sync.Once
-)
-
-// GetBlockchain returns a singleton blockchain instance
-func GetBlockchain() *Blockchain {
-	once.Do(func() {
-		blockchainInstance = CreateBlockchain()
-	})
-	return blockchainInstance
-}
-
-// GetAllBlocks returns all blocks from latest to genesis
+// GetAllBlocks returns all blocks from latest to genesis. A pruned node
+// stops at its oldest block that still has a body.
 func (bc *Blockchain) GetAllBlocks() []*Block {
 	var blocks []*Block
 	it := bc.Iterator()
+	prunedTo := bc.PruneHeight()
 
-	for {
+	for height := bc.BestHeight(); height > prunedTo || height == 0; height-- {
 		b := it.Next()
 		blocks = append(blocks, b)
 		if len(b.PrevBlockHash) == 0 {
@@ -0,0 +1,97 @@
+package block
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+func TestReorgFollowsChainWork(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+	spend := spendTx(w, cb, []int{0}, tx.NewTXOutput(cb.Vout[0].Value, testAddress))
+	a2 := newTestBlock(t, bc, b1, testAddress, spend)
+	if err := bc.AcceptBlock(a2); err != nil {
+		t.Fatal(err)
+	}
+	a3 := acceptTestBlocks(t, bc, a2, 1)
+
+	// a branch of equal work stays on the side
+	c2 := newTestBlock(t, bc, b1, testAddress)
+	if err := bc.AcceptBlock(c2); err != nil {
+		t.Fatal(err)
+	}
+	c3 := newTestBlock(t, bc, c2, testAddress)
+	if err := bc.AcceptBlock(c3); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bc.Tip(), a3.Hash) {
+		t.Fatalf("tip moved to a branch of equal work")
+	}
+
+	// one more block gives it the most work
+	c4 := newTestBlock(t, bc, c3, testAddress)
+	if err := bc.AcceptBlock(c4); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bc.Tip(), c4.Hash) || bc.BestHeight() != 4 {
+		t.Fatalf("tip is %x at height %d, want c4 at 4", bc.Tip(), bc.BestHeight())
+	}
+	if _, ok := bc.findUTXO(cb.ID, 0); !ok {
+		t.Error("the coinbase spent on the old branch is not unspent again")
+	}
+	if _, ok := bc.findUTXO(spend.ID, 0); ok {
+		t.Error("an output of the old branch is still unspent")
+	}
+	for h, want := range []*Block{b1, c2, c3, c4} {
+		if got := bc.activeHash(int64(h + 1)); !bytes.Equal(got, want.Hash) {
+			t.Errorf("height %d is %x on the active chain, want %x", h+1, got, want.Hash)
+		}
+	}
+
+	// the UTXO set is the one of a chain that only ever saw the new branch
+	fresh := newTestChainFrom(t, bc, bc.params)
+	for _, b := range []*Block{b1, c2, c3, c4} {
+		if err := fresh.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := utxoSet(t, bc), utxoSet(t, fresh); !reflect.DeepEqual(got, want) {
+		t.Errorf("UTXO set after the reorg:\n%v\nwant:\n%v", got, want)
+	}
+
+	// and back again once the old branch outgrows it
+	a5 := acceptTestBlocks(t, bc, a3, 2)
+	if !bytes.Equal(bc.Tip(), a5.Hash) {
+		t.Error("tip did not move back to the branch with the most work")
+	}
+	if _, ok := bc.findUTXO(spend.ID, 0); !ok {
+		t.Error("the output of the reconnected branch is not unspent")
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	params := testParams()
+	bc := newTestChain(t, params)
+	genesis := tipBlock(t, bc)
+	b2 := acceptTestBlocks(t, bc, genesis, 2)
+
+	params.Checkpoints = []chaincfg.Checkpoint{{Height: 3, Hash: hex.EncodeToString(make([]byte, 32))}}
+	wantRuleError(t, bc.AcceptBlock(newTestBlock(t, bc, b2, testAddress)), ErrBadCheckpoint)
+
+	params.Checkpoints = []chaincfg.Checkpoint{{Height: 2, Hash: hex.EncodeToString(b2.Hash)}}
+	if err := bc.AcceptBlock(newTestBlock(t, bc, b2, testAddress)); err != nil {
+		t.Fatal(err)
+	}
+	// a branch at height 1 forks off below the checkpoint
+	wantRuleError(t, bc.AcceptBlock(newTestBlock(t, bc, genesis, testAddress)), ErrForkTooOld)
+}
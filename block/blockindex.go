@@ -0,0 +1,163 @@
+package block
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"math/big"
+)
+
+const (
+	// blockIndexBucket maps every stored block hash, on the active chain or
+	// on a side branch, to its height and the cumulative work of its chain
+	blockIndexBucket = "blockindex"
+
+	// heightIndexBucket maps the 8-byte big-endian height of every block on
+	// the active chain to its hash
+	heightIndexBucket = "heightindex"
+)
+
+// ErrBlockNotFound is returned when a block is not stored, or no block of
+// the active chain has the requested height
+var ErrBlockNotFound = errors.New("block not found")
+
+type blockIndexEntry struct {
+	Height    int64
+	ChainWork *big.Int
+}
+
+func (e blockIndexEntry) serialize() []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(e.Height))
+	return append(buf, e.ChainWork.Bytes()...)
+}
+
+func deserializeIndexEntry(d []byte) blockIndexEntry {
+	return blockIndexEntry{
+		Height:    int64(binary.BigEndian.Uint64(d[:8])),
+		ChainWork: new(big.Int).SetBytes(d[8:]),
+	}
+}
+
+// readIndexEntry looks up a block in the index of an open transaction
+func readIndexEntry(txn StoreTx, hash []byte) (blockIndexEntry, bool) {
+	d := txn.Bucket(blockIndexBucket).Get(hash)
+	if d == nil {
+		return blockIndexEntry{}, false
+	}
+	return deserializeIndexEntry(d), true
+}
+
+// heightMatches reports whether the height a stored block carries agrees
+// with height. Blocks stored before heights were recorded carry zero, which
+// matches any height.
+func (b *Block) heightMatches(height int64) bool {
+	return b.Height == 0 || b.Height == height
+}
+
+// indexEntry looks up the height and chainwork of a stored block
+func (bc *Blockchain) indexEntry(hash []byte) (blockIndexEntry, bool) {
+	var entry blockIndexEntry
+	var ok bool
+
+	err := bc.store.View(func(txn StoreTx) error {
+		entry, ok = readIndexEntry(txn, hash)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return entry, ok
+}
+
+// ChainWork returns the total work of the chain ending at hash, or nil if
+// the block is unknown
+func (bc *Blockchain) ChainWork(hash []byte) *big.Int {
+	entry, ok := bc.indexEntry(hash)
+	if !ok {
+		return nil
+	}
+	return entry.ChainWork
+}
+
+// buildBlockIndex indexes a chain stored before the block index existed,
+// walking back from the tip and then filling entries in from genesis
+func (bc *Blockchain) buildBlockIndex(txn StoreTx) error {
+	idx, err := txn.CreateBucket(blockIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	var chain []*Block
+	for hash := txn.Tip(); len(hash) > 0; {
+		b := txn.GetBlock(hash)
+		chain = append(chain, b)
+		hash = b.PrevBlockHash
+	}
+
+	work := new(big.Int)
+	for i := len(chain) - 1; i >= 0; i-- {
+		b := chain[i]
+		work = new(big.Int).Add(work, bc.engine.Work(b))
+		entry := blockIndexEntry{Height: int64(len(chain) - 1 - i), ChainWork: work}
+		if err := idx.Put(b.Hash, entry.serialize()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func heightKey(height int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	return key
+}
+
+// BestHeight returns the height of the chain tip
+func (bc *Blockchain) BestHeight() int64 {
+	return bc.blockHeight(bc.Tip())
+}
+
+// GetBlockByHash returns the stored block with hash, on the active chain or
+// on a side branch. Blocks a pruned node no longer has the body of return
+// ErrBlockPruned.
+func (bc *Blockchain) GetBlockByHash(hash []byte) (*Block, error) {
+	b := bc.getBlock(hash)
+	if b == nil {
+		return nil, ErrBlockNotFound
+	}
+	// the index height is authoritative, see heightMatches
+	if entry, ok := bc.indexEntry(hash); ok {
+		b.Height = entry.Height
+	}
+	if bc.isPrunedBlock(hash, b.Height) {
+		return nil, ErrBlockPruned
+	}
+	return b, nil
+}
+
+// GetBlockByHeight returns the block at height on the active chain
+func (bc *Blockchain) GetBlockByHeight(height int64) (*Block, error) {
+	hash := bc.activeHash(height)
+	if hash == nil {
+		return nil, ErrBlockNotFound
+	}
+	return bc.GetBlockByHash(hash)
+}
+
+// activeHash returns the hash of the block at height on the active chain,
+// or nil when the chain is not that high
+func (bc *Blockchain) activeHash(height int64) []byte {
+	var hash []byte
+
+	err := bc.store.View(func(txn StoreTx) error {
+		if h := txn.Bucket(heightIndexBucket).Get(heightKey(height)); h != nil {
+			hash = append([]byte(nil), h...)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return hash
+}
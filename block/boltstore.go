@@ -0,0 +1,162 @@
+package block
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	blocksBucket = "blocks"
+	lastHashKey  = "lh"
+
+	// openTimeout is how long OpenBoltStore waits for another process to
+	// release the database file
+	openTimeout = time.Second
+)
+
+// BoltStore is a ChainStore in a BoltDB file. Blocks and the tip live in
+// the blocks bucket, the tip under lastHashKey; every index is a bucket of
+// its own.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens the database file at path, creating it and its
+// directory if needed. It fails when another process has the file open.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err == bolt.ErrTimeout {
+		return nil, fmt.Errorf("%s is in use by another process", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// View implements ChainStore
+func (s *BoltStore) View(fn func(StoreTx) error) error {
+	return s.db.View(func(txn *bolt.Tx) error {
+		return fn(boltTx{txn})
+	})
+}
+
+// Update implements ChainStore
+func (s *BoltStore) Update(fn func(StoreTx) error) error {
+	return s.db.Update(func(txn *bolt.Tx) error {
+		return fn(boltTx{txn})
+	})
+}
+
+// Close implements ChainStore
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+type boltTx struct {
+	txn *bolt.Tx
+}
+
+func (t boltTx) GetBlock(hash []byte) *Block {
+	blocks := t.txn.Bucket([]byte(blocksBucket))
+	if blocks == nil {
+		return nil
+	}
+	if encoded := blocks.Get(hash); encoded != nil {
+		return mustDeserialize(hash, encoded)
+	}
+	return nil
+}
+
+func (t boltTx) PutBlock(b *Block) error {
+	blocks, err := t.blocks()
+	if err != nil {
+		return err
+	}
+	return blocks.Put(b.Hash, b.Serialize())
+}
+
+func (t boltTx) BlockSize(hash []byte) int {
+	if blocks := t.txn.Bucket([]byte(blocksBucket)); blocks != nil {
+		return len(blocks.Get(hash))
+	}
+	return 0
+}
+
+func (t boltTx) Tip() []byte {
+	if blocks := t.txn.Bucket([]byte(blocksBucket)); blocks != nil {
+		return blocks.Get([]byte(lastHashKey))
+	}
+	return nil
+}
+
+func (t boltTx) SetTip(hash []byte) error {
+	blocks, err := t.blocks()
+	if err != nil {
+		return err
+	}
+	return blocks.Put([]byte(lastHashKey), hash)
+}
+
+// blocks returns the blocks bucket, creating it in a new database
+func (t boltTx) blocks() (*bolt.Bucket, error) {
+	if !t.txn.Writable() {
+		return nil, ErrTxNotWritable
+	}
+	return t.txn.CreateBucketIfNotExists([]byte(blocksBucket))
+}
+
+func (t boltTx) Bucket(name string) StoreBucket {
+	if b := t.txn.Bucket([]byte(name)); b != nil {
+		return boltBucket{b}
+	}
+	return nil
+}
+
+func (t boltTx) CreateBucket(name string) (StoreBucket, error) {
+	b, err := t.txn.CreateBucket([]byte(name))
+	if err != nil {
+		return nil, boltError(err)
+	}
+	return boltBucket{b}, nil
+}
+
+func (t boltTx) DeleteBucket(name string) error {
+	return boltError(t.txn.DeleteBucket([]byte(name)))
+}
+
+type boltBucket struct {
+	*bolt.Bucket
+}
+
+func (b boltBucket) Put(key, value []byte) error {
+	return boltError(b.Bucket.Put(key, value))
+}
+
+func (b boltBucket) Delete(key []byte) error {
+	return boltError(b.Bucket.Delete(key))
+}
+
+func (b boltBucket) Cursor() StoreCursor {
+	return b.Bucket.Cursor()
+}
+
+// boltError maps bolt errors to their ChainStore equivalents
+func boltError(err error) error {
+	switch err {
+	case bolt.ErrTxNotWritable:
+		return ErrTxNotWritable
+	case bolt.ErrBucketExists:
+		return ErrBucketExists
+	case bolt.ErrBucketNotFound:
+		return ErrBucketNotFound
+	}
+	return err
+}
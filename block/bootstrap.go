@@ -0,0 +1,300 @@
+package block
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/Shubham0699/go-mini-blockchain/wire"
+)
+
+// A bootstrap file carries the active chain of one network, genesis first,
+// so a new node can start from it instead of copying a database:
+//
+//	4 bytes   magic "GMBC"
+//	uint32    format version (bootstrapVersion)
+//	bytes     network name
+//	then one record per block, in height order:
+//	uint32    length of the serialized block
+//	...       the block (see EncodingVersion)
+//
+// Integers are little-endian. The whole file may be gzip-compressed;
+// readers detect that from the gzip header.
+const (
+	bootstrapMagic   = "GMBC"
+	bootstrapVersion = 1
+
+	// maxBootstrapRecord bounds a record before it is read, here and in
+	// UTXO set snapshots; AcceptBlock then applies the real block size limit
+	maxBootstrapRecord = 32 << 20
+
+	// bootstrapProgressInterval is how many blocks pass between progress
+	// logs of an export or import
+	bootstrapProgressInterval = 1000
+)
+
+// ImportResult counts the blocks an import read from a bootstrap file
+type ImportResult struct {
+	Blocks   int64 // records read, genesis included
+	Imported int64 // blocks validated and connected
+	Skipped  int64 // blocks already stored, e.g. by an interrupted import
+}
+
+// BootstrapWriter writes a bootstrap file
+type BootstrapWriter struct {
+	w  io.Writer
+	gz *gzip.Writer
+}
+
+// NewBootstrapWriter writes the header of a bootstrap file for network to
+// w, gzip-compressing everything if compress is set
+func NewBootstrapWriter(w io.Writer, network string, compress bool) (*BootstrapWriter, error) {
+	bw := &BootstrapWriter{w: w}
+	if compress {
+		bw.gz = gzip.NewWriter(w)
+		bw.w = bw.gz
+	}
+
+	var h wire.Writer
+	h.PutUint32(bootstrapVersion)
+	h.PutBytes([]byte(network))
+	if _, err := bw.w.Write(append([]byte(bootstrapMagic), h.Bytes()...)); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// Write appends one block record
+func (bw *BootstrapWriter) Write(b *Block) error {
+	return writeRecord(bw.w, b.Serialize())
+}
+
+// writeRecord writes d prefixed with its uint32 length
+func writeRecord(w io.Writer, d []byte) error {
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(d)))
+	if _, err := w.Write(n[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(d)
+	return err
+}
+
+// readRecord reads a record written by writeRecord. It returns io.EOF at
+// the end of r and io.ErrUnexpectedEOF inside a record.
+func readRecord(r io.Reader) ([]byte, error) {
+	var n [4]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(n[:])
+	if size > maxBootstrapRecord {
+		return nil, fmt.Errorf("record of %d bytes is too big", size)
+	}
+	d := make([]byte, size)
+	if _, err := io.ReadFull(r, d); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return d, nil
+}
+
+// Close flushes the compressed stream, if any. It does not close the
+// underlying writer.
+func (bw *BootstrapWriter) Close() error {
+	if bw.gz != nil {
+		return bw.gz.Close()
+	}
+	return nil
+}
+
+// BootstrapReader reads a bootstrap file
+type BootstrapReader struct {
+	r       io.Reader
+	Network string // network the file was exported from
+	Genesis *Block // first block of the file
+}
+
+// NewBootstrapReader reads the header and the genesis block of a bootstrap
+// file, plain or gzip-compressed
+func NewBootstrapReader(r io.Reader) (*BootstrapReader, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	} else {
+		r = br
+	}
+
+	head := make([]byte, len(bootstrapMagic)+8)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, fmt.Errorf("reading bootstrap header: %w", err)
+	}
+	if string(head[:len(bootstrapMagic)]) != bootstrapMagic {
+		return nil, errors.New("not a bootstrap file")
+	}
+	hr := wire.NewReader(head[len(bootstrapMagic):])
+	if v := hr.Uint32(); v != bootstrapVersion {
+		return nil, fmt.Errorf("unsupported bootstrap file version %d", v)
+	}
+	n := hr.Uint32()
+	if n > 256 {
+		return nil, fmt.Errorf("bootstrap network name of %d bytes", n)
+	}
+	network := make([]byte, n)
+	if _, err := io.ReadFull(r, network); err != nil {
+		return nil, fmt.Errorf("reading bootstrap header: %w", err)
+	}
+
+	b := &BootstrapReader{r: r, Network: string(network)}
+	genesis, err := b.Next()
+	if err == io.EOF {
+		return nil, errors.New("bootstrap file holds no blocks")
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.Genesis = genesis
+	return b, nil
+}
+
+// Next returns the next block of the file, or io.EOF after the last one
+func (br *BootstrapReader) Next() (*Block, error) {
+	d, err := readRecord(br.r)
+	if err == io.ErrUnexpectedEOF {
+		return nil, errors.New("bootstrap file ends inside a record")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Deserialize(d)
+}
+
+// ReadBootstrapHeaders reads the rest of a bootstrap file into the chain
+// of its block hashes, genesis first. Every header has to hash to its
+// block's hash and link to the block before, so the assume-valid hash
+// pins down all blocks below it; the blocks themselves are not checked.
+// The file is read again with a new BootstrapReader to import it.
+func ReadBootstrapHeaders(ctx context.Context, br *BootstrapReader) (*HeaderChain, error) {
+	hc := &HeaderChain{hashes: [][]byte{br.Genesis.Hash}}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		b, err := br.Next()
+		if err == io.EOF {
+			return hc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		height := int64(len(hc.hashes))
+		if header := b.Header(); !bytes.Equal(header.Hash(), b.Hash) {
+			return nil, fmt.Errorf("header of block %d hashes to %x, not %x", height, header.Hash(), b.Hash)
+		}
+		if !bytes.Equal(b.PrevBlockHash, hc.hashes[height-1]) {
+			return nil, fmt.Errorf("block %d does not link to block %d", height, height-1)
+		}
+		hc.hashes = append(hc.hashes, b.Hash)
+
+		if (height+1)%bootstrapProgressInterval == 0 {
+			log.Printf("📥 Read %d headers", height+1)
+		}
+	}
+}
+
+// ExportChain writes the active chain, genesis first, to w as a bootstrap
+// file and returns how many blocks it wrote. It follows the height index
+// as it goes and fails if a reorg changes it underneath.
+func (bc *Blockchain) ExportChain(ctx context.Context, w io.Writer, compress bool) (int64, error) {
+	if pruned := bc.PruneHeight(); pruned > 0 {
+		return 0, fmt.Errorf("cannot export: %w up to height %d", ErrBlockPruned, pruned)
+	}
+	bw, err := NewBootstrapWriter(w, bc.params.Name, compress)
+	if err != nil {
+		return 0, err
+	}
+
+	best := bc.BestHeight()
+	var prev []byte
+	for h := int64(0); h <= best; h++ {
+		if err := ctx.Err(); err != nil {
+			return h, err
+		}
+		b, err := bc.GetBlockByHeight(h)
+		if err != nil {
+			return h, fmt.Errorf("block %d: %w", h, err)
+		}
+		if h > 0 && !bytes.Equal(b.PrevBlockHash, prev) {
+			return h, fmt.Errorf("the active chain changed at height %d during the export", h)
+		}
+		if err := bw.Write(b); err != nil {
+			return h, err
+		}
+		prev = b.Hash
+
+		if (h+1)%bootstrapProgressInterval == 0 {
+			log.Printf("📤 Exported %d/%d blocks", h+1, best+1)
+		}
+	}
+	return best + 1, bw.Close()
+}
+
+// ImportChain reads the blocks of a bootstrap file and feeds every one
+// through AcceptBlock, so it is validated and connected like a block from
+// a peer. The file must come from the same network and genesis block; a
+// chain created with NewWithGenesis(store, params, br.Genesis) matches it.
+// Blocks that are already stored are skipped, so importing the same file
+// again resumes an import that was interrupted; each block is committed as
+// it is connected. The import stops at the first invalid block, or with
+// ctx.Err() once ctx is cancelled.
+func (bc *Blockchain) ImportChain(ctx context.Context, br *BootstrapReader) (ImportResult, error) {
+	var result ImportResult
+
+	if br.Network != bc.params.Name {
+		return result, fmt.Errorf("bootstrap file is for network %q, not %q", br.Network, bc.params.Name)
+	}
+	genesis, err := bc.GetBlockByHeight(0)
+	if err != nil {
+		return result, err
+	}
+	if !bytes.Equal(br.Genesis.Hash, genesis.Hash) {
+		return result, fmt.Errorf("bootstrap file starts from genesis %x, this chain from %x", br.Genesis.Hash, genesis.Hash)
+	}
+	result.Blocks, result.Skipped = 1, 1
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		b, err := br.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Blocks++
+
+		if bc.getBlock(b.Hash) != nil {
+			result.Skipped++
+			continue
+		}
+		if err := bc.AcceptBlock(b); err != nil {
+			return result, fmt.Errorf("block %d (%x): %w", b.Height, b.Hash, err)
+		}
+		result.Imported++
+
+		if result.Blocks%bootstrapProgressInterval == 0 {
+			log.Printf("📥 Imported up to height %d", b.Height)
+		}
+	}
+}
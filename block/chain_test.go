@@ -0,0 +1,151 @@
+package block
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+)
+
+// testAddress receives the coinbases of blocks that pay nobody in particular
+const testAddress = "00112233445566778899aabbccddeeff00112233"
+
+// testParams are the mainnet rules with the difficulty and the coinbase
+// maturity turned down, so test chains mine in microseconds
+func testParams() *chaincfg.Params {
+	p := chaincfg.MainNetParams
+	p.Name = "test"
+	p.GenesisBits, p.MinBits, p.MaxBits = 1, 1, 4
+	p.RetargetInterval = 1000
+	p.CoinbaseMaturity = 1
+	return &p
+}
+
+// newTestChain starts a chain with params in a MemStore
+func newTestChain(t *testing.T, params *chaincfg.Params) *Blockchain {
+	t.Helper()
+	bc, err := New(NewMemStore(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(bc.Close)
+	return bc
+}
+
+// testExtraNonce makes every coinbase of newTestBlock unique
+var testExtraNonce uint64
+
+// newTestBlock seals a block on parent without storing it: a coinbase
+// paying the subsidy to payTo, followed by txs
+func newTestBlock(t *testing.T, bc *Blockchain, parent *Block, payTo string, txs ...*tx.Transaction) *Block {
+	t.Helper()
+	height := bc.blockHeight(parent.Hash) + 1
+	testExtraNonce++
+	cb := tx.NewCoinbaseTX(payTo, int(bc.params.BlockSubsidy(height)), height, testExtraNonce)
+
+	b := newTxBlock(append([]*tx.Transaction{cb}, txs...), parent.Hash, 0)
+	b.Height = height
+	if mtp := bc.medianTimePast(parent); b.Timestamp <= mtp {
+		b.Timestamp = mtp + 1
+	}
+	if err := bc.engine.Prepare(bc, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.engine.Seal(context.Background(), bc, b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// acceptTestBlocks seals n blocks in a row on parent, passes each to
+// AcceptBlock and returns the last one
+func acceptTestBlocks(t *testing.T, bc *Blockchain, parent *Block, n int) *Block {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		b := newTestBlock(t, bc, parent, testAddress)
+		if err := bc.AcceptBlock(b); err != nil {
+			t.Fatalf("block %d on %x: %v", i, parent.Hash, err)
+		}
+		parent = b
+	}
+	return parent
+}
+
+// tipBlock returns the block at the tip of bc
+func tipBlock(t *testing.T, bc *Blockchain) *Block {
+	t.Helper()
+	b := bc.getBlock(bc.Tip())
+	if b == nil {
+		t.Fatal("tip block is missing")
+	}
+	return b
+}
+
+// spendTx returns a transaction signed by w spending outputs vouts of
+// prev, which have to be locked to w, and paying outs
+func spendTx(w *wallet.Wallet, prev *tx.Transaction, vouts []int, outs ...tx.TXOutput) *tx.Transaction {
+	t := &tx.Transaction{Vout: outs}
+	prevOuts := make(map[string]tx.TXOutput)
+	for _, vout := range vouts {
+		t.Vin = append(t.Vin, tx.TXInput{Txid: prev.ID, Vout: vout, PubKey: w.PubKey})
+		prevOuts[hex.EncodeToString(append(append([]byte(nil), prev.ID...), byte(vout)))] = prev.Vout[vout]
+	}
+	t.Sign(w.Private, prevOuts)
+	t.SetID()
+	return t
+}
+
+// newTestWallet creates a wallet or fails the test
+func newTestWallet(t *testing.T) *wallet.Wallet {
+	t.Helper()
+	w, err := wallet.NewWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// utxoSet returns the chainstate of bc as hex key -> hex entry
+func utxoSet(t *testing.T, bc *Blockchain) map[string]string {
+	t.Helper()
+	set := make(map[string]string)
+	err := bc.store.View(func(txn StoreTx) error {
+		return txn.Bucket(utxoBucket).ForEach(func(k, v []byte) error {
+			set[hex.EncodeToString(k)] = hex.EncodeToString(v)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+// wantRuleError fails the test unless err is a RuleError with code
+func wantRuleError(t *testing.T, err error, code ErrorCode) {
+	t.Helper()
+	var rerr RuleError
+	if !errors.As(err, &rerr) || rerr.ErrorCode != code {
+		t.Errorf("got error %v, want %v", err, code)
+	}
+}
+
+// newTestChainFrom starts a second chain in a MemStore from the genesis
+// block of bc, so blocks of one can be fed to the other
+func newTestChainFrom(t *testing.T, bc *Blockchain, params *chaincfg.Params) *Blockchain {
+	t.Helper()
+	genesis, err := bc.GetBlockByHeight(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewWithGenesis(NewMemStore(), params, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(other.Close)
+	return other
+}
@@ -0,0 +1,81 @@
+package block
+
+import (
+	"bytes"
+	"encoding/hex"
+)
+
+// checkCheckpoint rejects b at height when it conflicts with a checkpoint,
+// or when it forks off the active chain below the highest checkpoint
+// reached so far
+func (bc *Blockchain) checkCheckpoint(b *Block, height int64) error {
+	if cp, ok := bc.params.Checkpoint(height); ok && hex.EncodeToString(b.Hash) != cp.Hash {
+		return ruleError(ErrBadCheckpoint,
+			"block %x at height %d does not match checkpoint %s", b.Hash, height, cp.Hash)
+	}
+
+	// duplicates are turned away before this, so any new block at or below
+	// a height we already have is on a fork
+	if cp, ok := bc.params.LastCheckpoint(bc.BestHeight()); ok && height <= cp.Height {
+		return ruleError(ErrForkTooOld,
+			"block %x at height %d forks below checkpoint %d", b.Hash, height, cp.Height)
+	}
+	return nil
+}
+
+// headerChain gives the block hash at each height of a chain whose headers
+// are known ahead of its blocks
+type headerChain interface {
+	activeHash(height int64) []byte
+}
+
+// HeaderChain is the chain of headers of a bootstrap file, read with
+// ReadBootstrapHeaders
+type HeaderChain struct {
+	hashes [][]byte // by height
+}
+
+// Height is the height of the last header
+func (hc *HeaderChain) Height() int64 {
+	return int64(len(hc.hashes)) - 1
+}
+
+// Contains reports whether the block with hash is at height
+func (hc *HeaderChain) Contains(height int64, hash []byte) bool {
+	return bytes.Equal(hc.activeHash(height), hash)
+}
+
+func (hc *HeaderChain) activeHash(height int64) []byte {
+	if height < 0 || height >= int64(len(hc.hashes)) {
+		return nil
+	}
+	return hc.hashes[height]
+}
+
+// SetHeaderChain makes hc the header chain assume-valid looks at, e.g. the
+// one of a bootstrap file before it is imported. It only tells which
+// blocks lead to the assume-valid block: blocks off it, such as those of
+// peers on another branch, are checked in full as before.
+func (bc *Blockchain) SetHeaderChain(hc *HeaderChain) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.headers = hc
+}
+
+// assumedValid reports whether the signature checks of b at height are
+// skipped. That is only the case when b is an ancestor of the assume-valid
+// block on a header chain known ahead of the blocks: the headers of a
+// bootstrap file being imported (SetHeaderChain), or the ones of a loaded
+// UTXO set snapshot whose history is being replayed. Any other block,
+// forks below the assume-valid height included, is fully checked.
+func (bc *Blockchain) assumedValid(b *Block, height int64) bool {
+	av := bc.params.AssumeValid
+	bc.mu.RLock()
+	headers := bc.headers
+	bc.mu.RUnlock()
+	if av == nil || height > av.Height || headers == nil {
+		return false
+	}
+	return hex.EncodeToString(headers.activeHash(av.Height)) == av.Hash &&
+		bytes.Equal(headers.activeHash(height), b.Hash)
+}
@@ -0,0 +1,144 @@
+package block
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+// forgedChain returns a bootstrap file of a chain whose block 2 spends the
+// coinbase of block 1 with a forged signature, and the hash of block 3 on
+// top of it
+func forgedChain(t *testing.T, params *chaincfg.Params) ([]byte, *Block, []byte) {
+	t.Helper()
+	bc := newTestChain(t, params)
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+
+	cb := b1.Transactions[0]
+	forged := spendTx(w, cb, []int{0}, tx.NewTXOutput(cb.Vout[0].Value, testAddress))
+	forged.Vin[0].Signature[5] ^= 1
+	forged.SetID()
+	b2 := newTestBlock(t, bc, b1, testAddress, forged)
+	appendUnchecked(t, bc, b2)
+	b3 := newTestBlock(t, bc, b2, testAddress)
+	appendUnchecked(t, bc, b3)
+
+	var file bytes.Buffer
+	if _, err := bc.ExportChain(context.Background(), &file, false); err != nil {
+		t.Fatal(err)
+	}
+	return file.Bytes(), b1, b3.Hash
+}
+
+// importForged imports file into a new chain, with its header chain if
+// withHeaders is set
+func importForged(t *testing.T, params *chaincfg.Params, file []byte, withHeaders bool) (*Blockchain, error) {
+	t.Helper()
+	br, err := NewBootstrapReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc, err := NewWithGenesis(NewMemStore(), params, br.Genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(bc.Close)
+
+	if withHeaders {
+		hr, err := NewBootstrapReader(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers, err := ReadBootstrapHeaders(context.Background(), hr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if headers.Height() != 3 {
+			t.Fatalf("header chain ends at height %d, want 3", headers.Height())
+		}
+		bc.SetHeaderChain(headers)
+	}
+	_, err = bc.ImportChain(context.Background(), br)
+	return bc, err
+}
+
+func TestAssumeValidSkipsSignaturesOnHeaderChain(t *testing.T) {
+	params := testParams()
+	file, b1, avHash := forgedChain(t, params)
+	params.AssumeValid = &chaincfg.Checkpoint{Height: 3, Hash: hex.EncodeToString(avHash)}
+
+	bc, err := importForged(t, params, file, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bc.BestHeight() != 3 {
+		t.Errorf("imported up to height %d, want 3", bc.BestHeight())
+	}
+
+	// the header chain does not vouch for a fork of it
+	w := newTestWallet(t)
+	cb := b1.Transactions[0]
+	forged := spendTx(w, cb, []int{0}, tx.NewTXOutput(1, testAddress))
+	wantRuleError(t, bc.AcceptBlock(newTestBlock(t, bc, b1, testAddress, forged)), ErrBadTxSignature)
+}
+
+func TestAssumeValidChecksSignaturesWithoutHeaderChain(t *testing.T) {
+	params := testParams()
+	file, _, avHash := forgedChain(t, params)
+
+	tests := []struct {
+		name        string
+		av          *chaincfg.Checkpoint
+		withHeaders bool
+	}{
+		{"no header chain", &chaincfg.Checkpoint{Height: 3, Hash: hex.EncodeToString(avHash)}, false},
+		{"header chain misses the assume-valid block", &chaincfg.Checkpoint{Height: 3, Hash: hex.EncodeToString(make([]byte, 32))}, true},
+		{"no assume-valid block", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *params
+			p.AssumeValid = tt.av
+			bc, err := importForged(t, &p, file, tt.withHeaders)
+			wantRuleError(t, err, ErrBadTxSignature)
+			if bc.BestHeight() != 1 {
+				t.Errorf("import stopped at height %d, want 1", bc.BestHeight())
+			}
+		})
+	}
+}
+
+func TestReadBootstrapHeadersRejectsBrokenLinks(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	b1 := acceptTestBlocks(t, bc, tipBlock(t, bc), 1)
+	orphan := newTestBlock(t, bc, b1, testAddress)
+	orphan.PrevBlockHash = make([]byte, 32)
+	header := orphan.Header()
+	orphan.Hash = header.Hash()
+
+	var file bytes.Buffer
+	bw, err := NewBootstrapWriter(&file, bc.params.Name, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []*Block{tipBlock(t, bc), orphan} {
+		if err := bw.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	br, err := NewBootstrapReader(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBootstrapHeaders(context.Background(), br); err == nil {
+		t.Error("header chain with a broken link: no error")
+	}
+}
@@ -0,0 +1,182 @@
+package block
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Shubham0699/go-mini-blockchain/consensus"
+)
+
+// ErrorCode identifies the consensus rule a block broke
+type ErrorCode int
+
+const (
+	// ErrDuplicateBlock means the block is already stored
+	ErrDuplicateBlock ErrorCode = iota
+
+	// ErrMissingParent means the previous block is unknown
+	ErrMissingParent
+
+	// ErrUnexpectedDifficulty means the bits break the retarget rule
+	ErrUnexpectedDifficulty
+
+	// ErrHighHash means the hash does not meet the block's target
+	ErrHighHash
+
+	// ErrBadHash means the stored hash does not match the block header
+	ErrBadHash
+
+	// ErrBadMerkleRoot means the Merkle root does not match the contents
+	ErrBadMerkleRoot
+
+	// ErrBadVersion means the header version is unknown to this node
+	ErrBadVersion
+
+	// ErrTimeTooOld means the timestamp is not after the median time of
+	// the previous blocks
+	ErrTimeTooOld
+
+	// ErrTimeTooNew means the timestamp is too far ahead of network time
+	ErrTimeTooNew
+
+	// ErrBadHeight means the height a block carries does not follow its
+	// parent
+	ErrBadHeight
+
+	// ErrBadCheckpoint means a block does not match the checkpoint at its
+	// height
+	ErrBadCheckpoint
+
+	// ErrForkTooOld means a block forks off the chain below a checkpoint
+	ErrForkTooOld
+
+	// ErrBlockTooBig means the serialized block exceeds MaxBlockSize
+	ErrBlockTooBig
+
+	// ErrTooManyTransactions means the block exceeds MaxBlockTxs
+	ErrTooManyTransactions
+
+	// ErrTooManySigOps means the block exceeds MaxBlockSigOps
+	ErrTooManySigOps
+
+	// ErrMultipleCoinbases means a coinbase appears after the first
+	// transaction
+	ErrMultipleCoinbases
+
+	// ErrMissingTxOut means a transaction spends an output that does not
+	// exist or that an earlier input of the block already spent
+	ErrMissingTxOut
+
+	// ErrBadTxOutValue means a transaction output is negative or above
+	// MaxSupply, or the inputs, outputs or fees add up to more than it
+	ErrBadTxOutValue
+
+	// ErrSpendTooHigh means a transaction pays out more than its inputs
+	ErrSpendTooHigh
+
+	// ErrBadCoinbaseHeight means the coinbase does not commit to the height
+	// of its block
+	ErrBadCoinbaseHeight
+
+	// ErrImmatureSpend means a transaction spends a coinbase that is not yet
+	// CoinbaseMaturity blocks deep
+	ErrImmatureSpend
+
+	// ErrBadTxSignature means an input is not signed by the owner of the
+	// output it spends
+	ErrBadTxSignature
+
+	// ErrBadCoinbaseValue means the coinbase pays more than subsidy plus fees
+	ErrBadCoinbaseValue
+
+	// ErrBadGenesis means a genesis block handed to a new chain is not a
+	// genesis block of its network
+	ErrBadGenesis
+
+	// ErrBadTxID means a transaction ID does not match the transaction
+	ErrBadTxID
+
+	// ErrNoTxInputs means a transaction other than the coinbase has no
+	// inputs
+	ErrNoTxInputs
+
+	// ErrOverwriteTx means a transaction creates an output that is already
+	// unspent, which would overwrite it
+	ErrOverwriteTx
+
+	// ErrReorgTooDeep means a reorg would disconnect blocks whose bodies
+	// and undo data a pruned node no longer has
+	ErrReorgTooDeep
+
+	// ErrInvalidSeal means the consensus engine rejected the block's seal
+	// (e.g. an unauthorised or out-of-turn proof-of-authority signer)
+	ErrInvalidSeal
+)
+
+var errorCodeStrings = map[ErrorCode]string{
+	ErrDuplicateBlock:       "ErrDuplicateBlock",
+	ErrMissingParent:        "ErrMissingParent",
+	ErrUnexpectedDifficulty: "ErrUnexpectedDifficulty",
+	ErrHighHash:             "ErrHighHash",
+	ErrBadHash:              "ErrBadHash",
+	ErrBadMerkleRoot:        "ErrBadMerkleRoot",
+	ErrBadVersion:           "ErrBadVersion",
+	ErrTimeTooOld:           "ErrTimeTooOld",
+	ErrTimeTooNew:           "ErrTimeTooNew",
+	ErrBadHeight:            "ErrBadHeight",
+	ErrBadCheckpoint:        "ErrBadCheckpoint",
+	ErrForkTooOld:           "ErrForkTooOld",
+	ErrBlockTooBig:          "ErrBlockTooBig",
+	ErrTooManyTransactions:  "ErrTooManyTransactions",
+	ErrTooManySigOps:        "ErrTooManySigOps",
+	ErrMultipleCoinbases:    "ErrMultipleCoinbases",
+	ErrMissingTxOut:         "ErrMissingTxOut",
+	ErrBadTxOutValue:        "ErrBadTxOutValue",
+	ErrSpendTooHigh:         "ErrSpendTooHigh",
+	ErrBadCoinbaseHeight:    "ErrBadCoinbaseHeight",
+	ErrImmatureSpend:        "ErrImmatureSpend",
+	ErrBadTxSignature:       "ErrBadTxSignature",
+	ErrBadCoinbaseValue:     "ErrBadCoinbaseValue",
+	ErrBadGenesis:           "ErrBadGenesis",
+	ErrBadTxID:              "ErrBadTxID",
+	ErrNoTxInputs:           "ErrNoTxInputs",
+	ErrOverwriteTx:          "ErrOverwriteTx",
+	ErrReorgTooDeep:         "ErrReorgTooDeep",
+	ErrInvalidSeal:          "ErrInvalidSeal",
+}
+
+func (e ErrorCode) String() string {
+	if s, ok := errorCodeStrings[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown ErrorCode (%d)", int(e))
+}
+
+// RuleError is returned when a block breaks a consensus rule
+type RuleError struct {
+	ErrorCode   ErrorCode
+	Description string
+}
+
+func (e RuleError) Error() string {
+	return e.Description
+}
+
+func ruleError(c ErrorCode, format string, args ...interface{}) RuleError {
+	return RuleError{ErrorCode: c, Description: fmt.Sprintf(format, args...)}
+}
+
+// sealErrorCode maps a consensus engine error to its rule error code
+func sealErrorCode(err error) ErrorCode {
+	switch {
+	case errors.Is(err, consensus.ErrUnknownParent):
+		return ErrMissingParent
+	case errors.Is(err, consensus.ErrUnexpectedDifficulty):
+		return ErrUnexpectedDifficulty
+	case errors.Is(err, consensus.ErrBadHash):
+		return ErrBadHash
+	case errors.Is(err, consensus.ErrHighHash):
+		return ErrHighHash
+	}
+	return ErrInvalidSeal
+}
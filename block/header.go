@@ -0,0 +1,87 @@
+package block
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// BlockVersion is the header version written by this node
+const BlockVersion = 1
+
+// BlockHeaderSize is the length of a serialized BlockHeader
+const BlockHeaderSize = 4 + 32 + 32 + 8 + 4 + 8
+
+// BlockHeader is the fixed-layout part of a block that its hash commits to.
+// Transactions (or the Data payload) are covered through MerkleRoot.
+//
+// Serialized layout, all integers little-endian:
+//
+//	offset  size  field
+//	     0     4  Version
+//	     4    32  PrevBlock (all zero for genesis)
+//	    36    32  MerkleRoot
+//	    68     8  Timestamp (unix seconds)
+//	    76     4  Bits
+//	    80     8  Nonce
+type BlockHeader struct {
+	Version    uint32
+	PrevBlock  [32]byte
+	MerkleRoot [32]byte
+	Timestamp  int64
+	Bits       uint32
+	Nonce      int64
+}
+
+// Header returns the header of b
+func (b *Block) Header() BlockHeader {
+	h := BlockHeader{
+		Version:   b.Version,
+		Timestamp: b.Timestamp,
+		Bits:      b.Bits,
+		Nonce:     b.Nonce,
+	}
+	copy(h.PrevBlock[:], b.PrevBlockHash)
+	copy(h.MerkleRoot[:], b.MerkleRoot)
+	return h
+}
+
+// SerializeHeader implements proof.BlockData
+func (b *Block) SerializeHeader() []byte {
+	h := b.Header()
+	return h.Serialize()
+}
+
+// Serialize encodes the header in its canonical binary form
+func (h *BlockHeader) Serialize() []byte {
+	buf := make([]byte, BlockHeaderSize)
+	binary.LittleEndian.PutUint32(buf[0:4], h.Version)
+	copy(buf[4:36], h.PrevBlock[:])
+	copy(buf[36:68], h.MerkleRoot[:])
+	binary.LittleEndian.PutUint64(buf[68:76], uint64(h.Timestamp))
+	binary.LittleEndian.PutUint32(buf[76:80], h.Bits)
+	binary.LittleEndian.PutUint64(buf[80:88], uint64(h.Nonce))
+	return buf
+}
+
+// DeserializeHeader decodes a header produced by Serialize
+func DeserializeHeader(d []byte) (*BlockHeader, error) {
+	if len(d) != BlockHeaderSize {
+		return nil, fmt.Errorf("block header must be %d bytes, got %d", BlockHeaderSize, len(d))
+	}
+	h := &BlockHeader{
+		Version:   binary.LittleEndian.Uint32(d[0:4]),
+		Timestamp: int64(binary.LittleEndian.Uint64(d[68:76])),
+		Bits:      binary.LittleEndian.Uint32(d[76:80]),
+		Nonce:     int64(binary.LittleEndian.Uint64(d[80:88])),
+	}
+	copy(h.PrevBlock[:], d[4:36])
+	copy(h.MerkleRoot[:], d[36:68])
+	return h, nil
+}
+
+// Hash is the block identity: SHA-256 over the serialized header only
+func (h *BlockHeader) Hash() []byte {
+	sum := sha256.Sum256(h.Serialize())
+	return sum[:]
+}
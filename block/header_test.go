@@ -0,0 +1,103 @@
+package block
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// goldenHeader and its encoding pin the 88-byte layout documented on
+// BlockHeader; the bytes were produced independently of this package
+var goldenHeader = BlockHeader{
+	Version:    1,
+	PrevBlock:  fill32(0x11),
+	MerkleRoot: fill32(0x22),
+	Timestamp:  1700000000,
+	Bits:       16,
+	Nonce:      0x0102030405060708,
+}
+
+func fill32(b byte) (out [32]byte) {
+	for i := range out {
+		out[i] = b
+	}
+	return out
+}
+
+const (
+	goldenHeaderHex = "01000000" +
+		"1111111111111111111111111111111111111111111111111111111111111111" +
+		"2222222222222222222222222222222222222222222222222222222222222222" +
+		"00f1536500000000" +
+		"10000000" +
+		"0807060504030201"
+	goldenHeaderHash = "c5f1eeecad72dbba45225bcb42edfac030a26277acfd3bfbe03b3b5b15d4e6eb"
+)
+
+func TestHeaderSerializeGolden(t *testing.T) {
+	got := goldenHeader.Serialize()
+	if len(got) != BlockHeaderSize {
+		t.Fatalf("serialized header is %d bytes, want %d", len(got), BlockHeaderSize)
+	}
+	if hex.EncodeToString(got) != goldenHeaderHex {
+		t.Errorf("serialized header\n got %x\nwant %s", got, goldenHeaderHex)
+	}
+	if h := hex.EncodeToString(goldenHeader.Hash()); h != goldenHeaderHash {
+		t.Errorf("header hash %s, want %s", h, goldenHeaderHash)
+	}
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	raw, _ := hex.DecodeString(goldenHeaderHex)
+	h, err := DeserializeHeader(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *h != goldenHeader {
+		t.Errorf("decoded %+v, want %+v", *h, goldenHeader)
+	}
+	if !bytes.Equal(h.Serialize(), raw) {
+		t.Error("re-encoding the decoded header changes it")
+	}
+}
+
+func TestDeserializeHeaderRejectsWrongLength(t *testing.T) {
+	raw, _ := hex.DecodeString(goldenHeaderHex)
+	for _, d := range [][]byte{nil, raw[:BlockHeaderSize-1], append(raw, 0)} {
+		if _, err := DeserializeHeader(d); err == nil {
+			t.Errorf("%d bytes: no error", len(d))
+		}
+	}
+}
+
+func TestBlockHeaderCommitsToFields(t *testing.T) {
+	b := &Block{
+		Version:       goldenHeader.Version,
+		PrevBlockHash: goldenHeader.PrevBlock[:],
+		MerkleRoot:    goldenHeader.MerkleRoot[:],
+		Timestamp:     goldenHeader.Timestamp,
+		Bits:          goldenHeader.Bits,
+		Nonce:         goldenHeader.Nonce,
+		Height:        5, // not part of the header
+	}
+	if hex.EncodeToString(b.SerializeHeader()) != goldenHeaderHex {
+		t.Fatalf("block header %x, want %s", b.SerializeHeader(), goldenHeaderHex)
+	}
+
+	base := b.Header()
+	changes := []func(h *BlockHeader){
+		func(h *BlockHeader) { h.Version++ },
+		func(h *BlockHeader) { h.PrevBlock[31] ^= 1 },
+		func(h *BlockHeader) { h.MerkleRoot[0] ^= 1 },
+		func(h *BlockHeader) { h.Timestamp++ },
+		func(h *BlockHeader) { h.Bits++ },
+		func(h *BlockHeader) { h.Nonce++ },
+	}
+	for i, change := range changes {
+		h := base
+		change(&h)
+		if bytes.Equal(h.Hash(), base.Hash()) {
+			t.Errorf("change %d does not alter the header hash", i)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package block
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// medianTimeBlocks is how many previous blocks median-time-past uses
+	medianTimeBlocks = 11
+
+	// maxMedianTimeSamples bounds how many peer clocks are remembered; later
+	// peers are not sampled at all, so they cannot push earlier ones out
+	maxMedianTimeSamples = 200
+
+	// minMedianTimeSamples is how many peers must report before the local
+	// clock is adjusted at all
+	minMedianTimeSamples = 5
+
+	// maxAllowedOffset caps how far peers can move our notion of time
+	maxAllowedOffset = 70 * time.Minute
+)
+
+// MedianTimeSource provides network-adjusted time: the local clock shifted
+// by the median offset reported by connected peers. A sourceID names the
+// peer's host, not its address and port, so one machine counts once
+// however many connections it opens.
+type MedianTimeSource interface {
+	AdjustedTime() time.Time
+	AddTimeSample(sourceID string, timeVal time.Time)
+	Offset() time.Duration
+}
+
+type medianTime struct {
+	mu      sync.Mutex
+	offsets map[string]time.Duration // one sample per peer host
+	offset  time.Duration
+}
+
+// NewMedianTime creates a time source with no samples, i.e. the local clock
+func NewMedianTime() MedianTimeSource {
+	return &medianTime{offsets: make(map[string]time.Duration)}
+}
+
+// AdjustedTime returns the local time plus the median peer offset
+func (m *medianTime) AdjustedTime() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return time.Unix(time.Now().Add(m.offset).Unix(), 0)
+}
+
+// AddTimeSample records the time reported by a peer host. Only the first
+// sample per host counts, and none once maxMedianTimeSamples hosts have
+// reported, so reconnecting or opening many connections from one machine
+// does not drag the median around.
+func (m *medianTime) AddTimeSample(sourceID string, timeVal time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.offsets[sourceID]; ok || len(m.offsets) >= maxMedianTimeSamples {
+		return
+	}
+	m.offsets[sourceID] = time.Until(timeVal).Round(time.Second)
+
+	if len(m.offsets) < minMedianTimeSamples {
+		return
+	}
+	sorted := make([]time.Duration, 0, len(m.offsets))
+	for _, o := range m.offsets {
+		sorted = append(sorted, o)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	median := sorted[len(sorted)/2]
+	if median > maxAllowedOffset || median < -maxAllowedOffset {
+		median = 0 // peers disagree too much with us, trust the local clock
+	}
+	m.offset = median
+}
+
+// Offset returns the current adjustment applied to the local clock
+func (m *medianTime) Offset() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.offset
+}
+
+// medianTimePast returns the median timestamp of the last medianTimeBlocks
+// blocks ending at (and including) b
+func (bc *Blockchain) medianTimePast(b *Block) int64 {
+	var timestamps []int64
+	for i := 0; i < medianTimeBlocks && b != nil; i++ {
+		timestamps = append(timestamps, b.Timestamp)
+		if len(b.PrevBlockHash) == 0 {
+			break
+		}
+		b = bc.getBlock(b.PrevBlockHash)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	return timestamps[len(timestamps)/2]
+}
@@ -0,0 +1,279 @@
+package block
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemStore is a ChainStore kept entirely in memory, for tests and
+// simulations that should not touch disk. Updates are serialized with
+// views, and a failed Update is rolled back. Nesting a transaction inside
+// another on the same store deadlocks.
+type MemStore struct {
+	mu      sync.RWMutex
+	blocks  map[string][]byte // serialized blocks by hash
+	tip     []byte
+	buckets map[string]*memBucket
+	closed  bool
+}
+
+// NewMemStore returns an empty in-memory store
+func NewMemStore() *MemStore {
+	return &MemStore{
+		blocks:  make(map[string][]byte),
+		buckets: make(map[string]*memBucket),
+	}
+}
+
+// View implements ChainStore
+func (s *MemStore) View(fn func(StoreTx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	return fn(&memTx{store: s})
+}
+
+// Update implements ChainStore
+func (s *MemStore) Update(fn func(StoreTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	t := &memTx{store: s, writable: true}
+	err := fn(t)
+	if err != nil {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+	}
+	t.writable = false
+	return err
+}
+
+// Close implements ChainStore. The contents are dropped.
+func (s *MemStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.blocks, s.tip, s.buckets = nil, nil, nil
+	return nil
+}
+
+// memTx applies writes in place and records how to reverse each of them
+type memTx struct {
+	store    *MemStore
+	writable bool
+	undo     []func()
+}
+
+func (t *memTx) GetBlock(hash []byte) *Block {
+	if encoded, ok := t.store.blocks[string(hash)]; ok {
+		return mustDeserialize(hash, encoded)
+	}
+	return nil
+}
+
+func (t *memTx) PutBlock(b *Block) error {
+	if !t.writable {
+		return ErrTxNotWritable
+	}
+	key := string(b.Hash)
+	old, existed := t.store.blocks[key]
+	t.store.blocks[key] = b.Serialize()
+	t.undo = append(t.undo, func() {
+		if existed {
+			t.store.blocks[key] = old
+		} else {
+			delete(t.store.blocks, key)
+		}
+	})
+	return nil
+}
+
+func (t *memTx) BlockSize(hash []byte) int {
+	return len(t.store.blocks[string(hash)])
+}
+
+func (t *memTx) Tip() []byte {
+	return t.store.tip
+}
+
+func (t *memTx) SetTip(hash []byte) error {
+	if !t.writable {
+		return ErrTxNotWritable
+	}
+	old := t.store.tip
+	t.store.tip = append([]byte(nil), hash...)
+	t.undo = append(t.undo, func() { t.store.tip = old })
+	return nil
+}
+
+func (t *memTx) Bucket(name string) StoreBucket {
+	if b, ok := t.store.buckets[name]; ok {
+		return &memTxBucket{tx: t, b: b}
+	}
+	return nil
+}
+
+func (t *memTx) CreateBucket(name string) (StoreBucket, error) {
+	if !t.writable {
+		return nil, ErrTxNotWritable
+	}
+	if _, ok := t.store.buckets[name]; ok {
+		return nil, ErrBucketExists
+	}
+	b := &memBucket{values: make(map[string][]byte)}
+	t.store.buckets[name] = b
+	t.undo = append(t.undo, func() { delete(t.store.buckets, name) })
+	return &memTxBucket{tx: t, b: b}, nil
+}
+
+func (t *memTx) DeleteBucket(name string) error {
+	if !t.writable {
+		return ErrTxNotWritable
+	}
+	b, ok := t.store.buckets[name]
+	if !ok {
+		return ErrBucketNotFound
+	}
+	delete(t.store.buckets, name)
+	t.undo = append(t.undo, func() { t.store.buckets[name] = b })
+	return nil
+}
+
+// memBucket keeps its keys sorted next to the values so cursors can walk
+// them in order
+type memBucket struct {
+	keys   []string
+	values map[string][]byte
+}
+
+// search returns the position of the first key >= key
+func (b *memBucket) search(key string) int {
+	return sort.SearchStrings(b.keys, key)
+}
+
+func (b *memBucket) set(key string, value []byte) {
+	if _, ok := b.values[key]; !ok {
+		i := b.search(key)
+		b.keys = append(b.keys, "")
+		copy(b.keys[i+1:], b.keys[i:])
+		b.keys[i] = key
+	}
+	b.values[key] = value
+}
+
+func (b *memBucket) remove(key string) {
+	if _, ok := b.values[key]; !ok {
+		return
+	}
+	i := b.search(key)
+	b.keys = append(b.keys[:i], b.keys[i+1:]...)
+	delete(b.values, key)
+}
+
+// memTxBucket is a memBucket seen through a transaction
+type memTxBucket struct {
+	tx *memTx
+	b  *memBucket
+}
+
+func (m *memTxBucket) Get(key []byte) []byte {
+	return m.b.values[string(key)]
+}
+
+func (m *memTxBucket) Put(key, value []byte) error {
+	if !m.tx.writable {
+		return ErrTxNotWritable
+	}
+	k := string(key)
+	old, existed := m.b.values[k]
+	m.b.set(k, append([]byte{}, value...))
+	m.tx.undo = append(m.tx.undo, func() {
+		if existed {
+			m.b.set(k, old)
+		} else {
+			m.b.remove(k)
+		}
+	})
+	return nil
+}
+
+func (m *memTxBucket) Delete(key []byte) error {
+	if !m.tx.writable {
+		return ErrTxNotWritable
+	}
+	k := string(key)
+	old, existed := m.b.values[k]
+	if !existed {
+		return nil
+	}
+	m.b.remove(k)
+	m.tx.undo = append(m.tx.undo, func() { m.b.set(k, old) })
+	return nil
+}
+
+func (m *memTxBucket) ForEach(fn func(k, v []byte) error) error {
+	c := m.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memTxBucket) Cursor() StoreCursor {
+	return &memCursor{b: m.b}
+}
+
+// memCursor remembers the key it is on rather than a position, so the
+// bucket can change underneath it
+type memCursor struct {
+	b   *memBucket
+	key []byte // nil before the first move and after running off an end
+}
+
+func (c *memCursor) at(i int) ([]byte, []byte) {
+	if i < 0 || i >= len(c.b.keys) {
+		c.key = nil
+		return nil, nil
+	}
+	k := c.b.keys[i]
+	c.key = []byte(k)
+	return c.key, c.b.values[k]
+}
+
+func (c *memCursor) First() ([]byte, []byte) {
+	return c.at(0)
+}
+
+func (c *memCursor) Last() ([]byte, []byte) {
+	return c.at(len(c.b.keys) - 1)
+}
+
+func (c *memCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.at(c.b.search(string(seek)))
+}
+
+func (c *memCursor) Next() ([]byte, []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+	i := c.b.search(string(c.key))
+	if i < len(c.b.keys) && c.b.keys[i] == string(c.key) {
+		i++
+	}
+	return c.at(i)
+}
+
+func (c *memCursor) Prev() ([]byte, []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+	return c.at(c.b.search(string(c.key)) - 1)
+}
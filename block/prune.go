@@ -0,0 +1,212 @@
+package block
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const (
+	// prunedToKey in the metadata bucket holds the 8-byte big-endian height
+	// of the newest block whose body was pruned. Every active block from
+	// height 1 up to it keeps only its header fields; genesis is never
+	// pruned.
+	prunedToKey = "prunedto"
+
+	// prunedSupplyKey in the metadata bucket holds the 8-byte big-endian
+	// sum of the coinbase outputs of the pruned blocks, which IssuedSupply
+	// can no longer read from them
+	prunedSupplyKey = "prunedsupply"
+
+	// DefaultPruneDepth is how many recent blocks keep their bodies and
+	// undo data by default, bounding the deepest reorg a pruned node
+	// handles
+	DefaultPruneDepth = 288
+)
+
+// ErrBlockPruned is returned for blocks whose body a pruned node deleted
+var ErrBlockPruned = errors.New("block body has been pruned")
+
+// PruneTarget says how much block history a pruned node keeps. Bodies of
+// the Depth most recent blocks are always kept.
+type PruneTarget struct {
+	Blocks int64 // keep the bodies of this many recent blocks (0 = no count target)
+	Bytes  int64 // keep at most this many bytes of block bodies (0 = no size target)
+	Depth  int64 // deepest reorg the node must still be able to handle
+}
+
+// Enabled reports whether the target prunes anything
+func (t PruneTarget) Enabled() bool {
+	return t.Blocks > 0 || t.Bytes > 0
+}
+
+// ParsePruneTarget parses a --prune value: "<n>MB" keeps block bodies
+// within n megabytes, a plain number keeps the bodies of that many recent
+// blocks, and "" or "0" turns pruning off. depth is the reorg depth to
+// keep.
+func ParsePruneTarget(s string, depth int64) (PruneTarget, error) {
+	t := PruneTarget{Depth: depth}
+	if depth < 1 {
+		return t, fmt.Errorf("prune depth must be at least 1, got %d", depth)
+	}
+	if s == "" {
+		return t, nil
+	}
+
+	number, unit := s, ""
+	if strings.HasSuffix(strings.ToUpper(s), "MB") {
+		number, unit = s[:len(s)-2], "MB"
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return t, fmt.Errorf("invalid prune target %q: want a block count or a size like 550MB", s)
+	}
+	if unit == "MB" {
+		t.Bytes = n << 20
+	} else {
+		t.Blocks = n
+	}
+	return t, nil
+}
+
+// readPrunedTo returns the height of the newest pruned block, 0 if none
+func readPrunedTo(txn StoreTx) int64 {
+	if meta := txn.Bucket(metadataBucket); meta != nil {
+		if v := meta.Get([]byte(prunedToKey)); len(v) == 8 {
+			return int64(binary.BigEndian.Uint64(v))
+		}
+	}
+	return 0
+}
+
+// readPrunedSupply returns the coins paid out by pruned coinbases
+func readPrunedSupply(txn StoreTx) int64 {
+	if meta := txn.Bucket(metadataBucket); meta != nil {
+		if v := meta.Get([]byte(prunedSupplyKey)); len(v) == 8 {
+			return int64(binary.BigEndian.Uint64(v))
+		}
+	}
+	return 0
+}
+
+// pruneBlocks strips the bodies and undo data of the active blocks that
+// fall outside target, given the tip is at tipHeight, and returns how
+// many it pruned
+func pruneBlocks(txn StoreTx, target PruneTarget, tipHeight int64) (int64, error) {
+	prunedTo := readPrunedTo(txn)
+	heights := txn.Bucket(heightIndexBucket)
+
+	// limit ends up as the newest height to prune
+	limit := prunedTo
+	if target.Blocks > 0 && tipHeight-target.Blocks > limit {
+		limit = tipHeight - target.Blocks
+	}
+	if target.Bytes > 0 {
+		var total int64
+		for h := tipHeight; h > limit; h-- {
+			total += int64(txn.BlockSize(heights.Get(heightKey(h))))
+			if total > target.Bytes {
+				limit = h
+				break
+			}
+		}
+	}
+	if newest := tipHeight - target.Depth; limit > newest {
+		limit = newest
+	}
+	if limit <= prunedTo {
+		return 0, nil
+	}
+
+	undo := txn.Bucket(undoBucket)
+	supply := readPrunedSupply(txn)
+	for h := prunedTo + 1; h <= limit; h++ {
+		hash := heights.Get(heightKey(h))
+		b := txn.GetBlock(hash)
+		supply += coinbaseValue(b)
+		b.Data, b.Transactions = nil, nil
+		if err := txn.PutBlock(b); err != nil {
+			return 0, err
+		}
+		if err := undo.Delete(hash); err != nil {
+			return 0, err
+		}
+	}
+	meta := txn.Bucket(metadataBucket)
+	if err := meta.Put([]byte(prunedSupplyKey), heightKey(supply)); err != nil {
+		return 0, err
+	}
+	if err := meta.Put([]byte(prunedToKey), heightKey(limit)); err != nil {
+		return 0, err
+	}
+	return limit - prunedTo, nil
+}
+
+// SetPrune turns pruning on with target, deleting what already falls
+// outside it, or off for a zero target. Blocks pruned before stay pruned.
+// Pruning cannot be combined with the transaction index.
+func (bc *Blockchain) SetPrune(target PruneTarget) error {
+	if target.Enabled() && target.Depth < 1 {
+		return fmt.Errorf("prune depth must be at least 1, got %d", target.Depth)
+	}
+	if target.Enabled() && bc.HasTxIndex() {
+		return errors.New("pruning is not possible with the transaction index enabled")
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.prune = target
+	if !target.Enabled() {
+		return nil
+	}
+	return bc.store.Update(func(txn StoreTx) error {
+		tip, _ := readIndexEntry(txn, bc.tip)
+		n, err := pruneBlocks(txn, target, tip.Height)
+		if n > 0 {
+			log.Printf("✂️ Pruned %d block bodies, kept from height %d", n, readPrunedTo(txn)+1)
+		}
+		return err
+	})
+}
+
+// PruneHeight returns the height of the newest block whose body was
+// pruned, or 0 on a node that never pruned
+func (bc *Blockchain) PruneHeight() int64 {
+	var height int64
+	err := bc.store.View(func(txn StoreTx) error {
+		height = readPrunedTo(txn)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return height
+}
+
+// IsPruned reports whether any block body has been pruned
+func (bc *Blockchain) IsPruned() bool {
+	return bc.PruneHeight() > 0
+}
+
+// isPrunedBlock reports whether hash is an active block with its body
+// pruned. Reorgs below the prune height are refused, so a block at a
+// pruned height that is on the active chain stays there.
+func (bc *Blockchain) isPrunedBlock(hash []byte, height int64) bool {
+	pruned := false
+	err := bc.store.View(func(txn StoreTx) error {
+		if height < 1 || height > readPrunedTo(txn) {
+			return nil
+		}
+		active := txn.Bucket(heightIndexBucket).Get(heightKey(height))
+		pruned = string(active) == string(hash)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return pruned
+}
@@ -0,0 +1,38 @@
+package block
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPruneLimitsReorgs(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	b1 := acceptTestBlocks(t, bc, tipBlock(t, bc), 1)
+	tip := acceptTestBlocks(t, bc, b1, 5)
+	if err := bc.SetPrune(PruneTarget{Blocks: 2, Depth: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got := bc.PruneHeight(); got != 4 {
+		t.Fatalf("pruned up to height %d, want 4", got)
+	}
+	if _, err := bc.GetBlockByHeight(2); !errors.Is(err, ErrBlockPruned) {
+		t.Errorf("pruned block: got %v, want ErrBlockPruned", err)
+	}
+
+	// a branch forking at b1 would have to disconnect pruned blocks
+	wantRuleError(t, bc.AcceptBlock(newTestBlock(t, bc, b1, testAddress)), ErrReorgTooDeep)
+
+	// within the prune depth a branch can still take over
+	b5 := bc.getBlock(tip.PrevBlockHash)
+	c7 := acceptTestBlocks(t, bc, b5, 2)
+	if !bytes.Equal(bc.Tip(), c7.Hash) {
+		t.Error("a branch within the prune depth did not become the tip")
+	}
+	if got := bc.PruneHeight(); got != 5 {
+		t.Errorf("pruned up to height %d after the reorg, want 5", got)
+	}
+	if _, err := bc.Verify(VerifyBlocks); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,283 @@
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+)
+
+const (
+	// metadataBucket describes the database itself: the schema version of
+	// its layout under schemaVersionKey and the network it belongs to under
+	// networkKey
+	metadataBucket   = "metadata"
+	schemaVersionKey = "version"
+	networkKey       = "network"
+
+	// SchemaVersion is the database layout this node writes. Databases
+	// with a lower version are migrated on open; higher ones are refused.
+	SchemaVersion = 4
+
+	// migrationProgressInterval is how many items pass between progress
+	// logs of a migration
+	migrationProgressInterval = 10000
+)
+
+// ErrSchemaTooNew is returned when opening a database written by a newer
+// node
+var ErrSchemaTooNew = errors.New("database was written by a newer version")
+
+// ErrLegacyBlocks is returned when opening a database whose blocks predate
+// the versioned block header. Their hashes are not taken over a header and
+// they carry no difficulty bits, so they cannot be carried over.
+var ErrLegacyBlocks = errors.New("database holds blocks from before versioned block headers")
+
+// migration upgrades a database from version-1 to version. It runs in its
+// own store transaction, which also records the new version, so an
+// interrupted upgrade resumes at the first migration that did not finish.
+type migration struct {
+	version int
+	name    string
+	run     func(bc *Blockchain, txn StoreTx, progress func(done, total int)) error
+}
+
+// migrations upgrade databases that predate the metadata bucket (version
+// 0) step by step to SchemaVersion. They are kept in order; a new layout
+// change appends one and bumps SchemaVersion.
+var migrations = []migration{
+	{1, "re-encode blocks from gob to the binary format", migrateBinaryBlocks},
+	{2, "build the block index", migrateBlockIndex},
+	{3, "build the chain state", migrateChainState},
+	{4, "re-encode the undo data with 32-bit lengths", migrateUndoData},
+}
+
+// legacyUndoBucket held the undo data before schema 4, with 16-bit entry
+// lengths that wrapped around for outputs over 64 KiB
+const legacyUndoBucket = "undo"
+
+// readSchema returns the schema version and network recorded in the
+// database. Databases from before the metadata bucket report version 0 and
+// no network.
+func readSchema(txn StoreTx) (version int, network string) {
+	meta := txn.Bucket(metadataBucket)
+	if meta == nil {
+		return 0, ""
+	}
+	if v := meta.Get([]byte(schemaVersionKey)); len(v) == 4 {
+		version = int(binary.BigEndian.Uint32(v))
+	}
+	return version, string(meta.Get([]byte(networkKey)))
+}
+
+// writeSchema records version and network, creating the metadata bucket
+// if needed
+func writeSchema(txn StoreTx, version int, network string) error {
+	meta := txn.Bucket(metadataBucket)
+	if meta == nil {
+		var err error
+		if meta, err = txn.CreateBucket(metadataBucket); err != nil {
+			return err
+		}
+	}
+	v := make([]byte, 4)
+	binary.BigEndian.PutUint32(v, uint32(version))
+	if err := meta.Put([]byte(schemaVersionKey), v); err != nil {
+		return err
+	}
+	return meta.Put([]byte(networkKey), []byte(network))
+}
+
+// upgradeSchema checks that an existing database belongs to the network
+// of bc and is not newer than this node, then runs every migration it has
+// not had yet. An empty store is left alone; New writes its metadata with
+// the genesis block.
+func (bc *Blockchain) upgradeSchema() error {
+	var version int
+	var network string
+	var empty bool
+	err := bc.store.View(func(txn StoreTx) error {
+		empty = txn.Tip() == nil
+		version, network = readSchema(txn)
+		return nil
+	})
+	if err != nil || empty {
+		return err
+	}
+
+	if network != "" && network != bc.params.Name {
+		return fmt.Errorf("database belongs to network %q, not %q", network, bc.params.Name)
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%w: schema version %d, this node supports up to %d", ErrSchemaTooNew, version, SchemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		log.Printf("🗂️ Migrating database to schema %d: %s", m.version, m.name)
+		err := bc.store.Update(func(txn StoreTx) error {
+			progress := func(done, total int) {
+				if done%migrationProgressInterval == 0 || done == total {
+					log.Printf("🗂️ Schema %d: %d/%d", m.version, done, total)
+				}
+			}
+			if err := m.run(bc, txn, progress); err != nil {
+				return err
+			}
+			return writeSchema(txn, m.version, bc.params.Name)
+		})
+		if err != nil {
+			return fmt.Errorf("migrating database to schema %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// migrateBinaryBlocks rewrites blocks stored with encoding/gob in the
+// binary encoding. Transaction IDs and merkle roots are kept as stored, so
+// block hashes do not change; they were computed over gob, though, so
+// verifying these blocks beyond their headers reports them. Gob blocks
+// without a header version cannot be rewritten and fail the migration
+// with ErrLegacyBlocks. Only BoltStore can hold gob blocks.
+func migrateBinaryBlocks(bc *Blockchain, txn StoreTx, progress func(done, total int)) error {
+	blocks := txn.Bucket(blocksBucket)
+	if blocks == nil {
+		return nil
+	}
+
+	// collect the keys first: buckets must not change under ForEach
+	var keys [][]byte
+	err := blocks.ForEach(func(k, v []byte) error {
+		if !bytes.Equal(k, []byte(lastHashKey)) {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, k := range keys {
+		encoded := blocks.Get(k)
+		if _, err := Deserialize(encoded); err != nil {
+			var b Block
+			if err := gob.NewDecoder(bytes.NewReader(encoded)).Decode(&b); err != nil {
+				return fmt.Errorf("block %x is neither binary nor gob encoded: %v", k, err)
+			}
+			if b.Version == 0 {
+				return fmt.Errorf("%w: block %x has no header version; sync the chain again into a new database", ErrLegacyBlocks, k)
+			}
+			if err := blocks.Put(k, b.Serialize()); err != nil {
+				return err
+			}
+		}
+		progress(i+1, len(keys))
+	}
+	return nil
+}
+
+// migrateBlockIndex indexes chains stored before the block index existed
+func migrateBlockIndex(bc *Blockchain, txn StoreTx, progress func(done, total int)) error {
+	if txn.Bucket(blockIndexBucket) != nil {
+		return nil
+	}
+	return bc.buildBlockIndex(txn)
+}
+
+// migrateChainState builds the UTXO set and height index of chains stored
+// before them
+func migrateChainState(bc *Blockchain, txn StoreTx, progress func(done, total int)) error {
+	if hasChainState(txn) {
+		return nil
+	}
+	return buildChainState(txn)
+}
+
+// migrateUndoData moves the undo records of legacyUndoBucket to undoBucket
+// in the current encoding. Records are checked against the inputs of their
+// block; one that does not match, because an entry length wrapped around,
+// is dropped with a warning, and a reorg that has to disconnect that block
+// fails instead of corrupting the UTXO set.
+func migrateUndoData(bc *Blockchain, txn StoreTx, progress func(done, total int)) error {
+	old := txn.Bucket(legacyUndoBucket)
+	if old == nil {
+		return nil
+	}
+	undo := txn.Bucket(undoBucket)
+	if undo == nil {
+		var err error
+		if undo, err = txn.CreateBucket(undoBucket); err != nil {
+			return err
+		}
+	}
+
+	var keys, values [][]byte
+	err := old.ForEach(func(k, v []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		values = append(values, append([]byte(nil), v...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, k := range keys {
+		spent, ok := deserializeLegacyUndo(values[i])
+		if ok {
+			ok = undoMatchesBlock(spent, txn.GetBlock(k))
+		}
+		if !ok {
+			log.Printf("⚠️ Undo data of block %x is corrupt and was dropped; reorgs past it will fail", k)
+		} else if err := undo.Put(k, serializeUndo(spent)); err != nil {
+			return err
+		}
+		progress(i+1, len(keys))
+	}
+	return txn.DeleteBucket(legacyUndoBucket)
+}
+
+// deserializeLegacyUndo reads undo data of legacyUndoBucket: records of
+// key length (1) | key | entry length (2, big-endian) | entry
+func deserializeLegacyUndo(d []byte) ([]spentOutput, bool) {
+	var spent []spentOutput
+	for len(d) > 0 {
+		n := int(d[0])
+		if len(d) < 1+n+2 {
+			return nil, false
+		}
+		key := d[1 : 1+n]
+		m := int(binary.BigEndian.Uint16(d[1+n:]))
+		d = d[1+n+2:]
+		if m < utxoEntryMinSize || len(d) < m {
+			return nil, false
+		}
+		spent = append(spent, spentOutput{key: key, entry: deserializeUTXOEntry(d[:m])})
+		d = d[m:]
+	}
+	return spent, true
+}
+
+// undoMatchesBlock reports whether spent lists exactly the outputs the
+// inputs of b spend, in order
+func undoMatchesBlock(spent []spentOutput, b *Block) bool {
+	if b == nil {
+		return false
+	}
+	i := 0
+	for _, t := range b.Transactions {
+		if t.IsCoinbase() {
+			continue
+		}
+		for _, in := range t.Vin {
+			if i == len(spent) || !bytes.Equal(spent[i].key, outpointKey(in.Txid, in.Vout)) {
+				return false
+			}
+			i++
+		}
+	}
+	return i == len(spent)
+}
@@ -0,0 +1,89 @@
+package block
+
+import (
+	"errors"
+	"log"
+)
+
+// ChainStore is where a Blockchain keeps its blocks, its tip and its index
+// buckets. All access happens in transactions: View runs fn read-only, and
+// Update applies every write fn made, or none of them when fn returns an
+// error. BoltStore keeps the chain in a file; MemStore keeps it in memory
+// for tests and simulations.
+type ChainStore interface {
+	View(fn func(StoreTx) error) error
+	Update(fn func(StoreTx) error) error
+	Close() error
+}
+
+// StoreTx is one transaction on a ChainStore. Slices it returns are only
+// valid until the transaction ends and must not be modified.
+type StoreTx interface {
+	// GetBlock returns the stored block with hash, or nil
+	GetBlock(hash []byte) *Block
+
+	// PutBlock stores b under its hash, replacing any block stored there
+	PutBlock(b *Block) error
+
+	// BlockSize returns the stored size of the block with hash, or 0
+	BlockSize(hash []byte) int
+
+	// Tip returns the hash of the chain tip, or nil in an empty store
+	Tip() []byte
+
+	// SetTip records hash as the chain tip
+	SetTip(hash []byte) error
+
+	// Bucket returns the index bucket name, or nil if it does not exist
+	Bucket(name string) StoreBucket
+
+	// CreateBucket creates the index bucket name, which must not exist
+	CreateBucket(name string) (StoreBucket, error)
+
+	// DeleteBucket drops the index bucket name and everything in it
+	DeleteBucket(name string) error
+}
+
+// StoreBucket is a key/value index kept sorted by key
+type StoreBucket interface {
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	ForEach(fn func(k, v []byte) error) error
+	Cursor() StoreCursor
+}
+
+// StoreCursor walks a StoreBucket in key order. Every move returns a nil
+// key once it runs off either end.
+type StoreCursor interface {
+	First() (key, value []byte)
+	Last() (key, value []byte)
+	Seek(seek []byte) (key, value []byte) // first key >= seek
+	Next() (key, value []byte)
+	Prev() (key, value []byte)
+}
+
+var (
+	// ErrTxNotWritable is returned for writes inside View
+	ErrTxNotWritable = errors.New("store transaction is read-only")
+
+	// ErrBucketExists is returned when creating a bucket that exists
+	ErrBucketExists = errors.New("bucket already exists")
+
+	// ErrBucketNotFound is returned when deleting a bucket that does not
+	// exist
+	ErrBucketNotFound = errors.New("bucket not found")
+
+	// ErrStoreClosed is returned for transactions on a closed MemStore
+	ErrStoreClosed = errors.New("store is closed")
+)
+
+// mustDeserialize decodes a block read back from a store. A stored block
+// that does not decode means the store is corrupt.
+func mustDeserialize(hash, encoded []byte) *Block {
+	b, err := Deserialize(encoded)
+	if err != nil {
+		log.Panicf("stored block %x: %v", hash, err)
+	}
+	return b
+}
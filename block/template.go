@@ -0,0 +1,131 @@
+package block
+
+import (
+	"errors"
+	"log"
+	"math/big"
+
+	"github.com/Shubham0699/go-mini-blockchain/consensus"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+// ErrTemplateUnsupported is returned for chains that are not mined with PoW
+var ErrTemplateUnsupported = errors.New("block templates are only available on proof-of-work chains")
+
+// BlockTemplate is an unsealed block handed to an external miner. The miner
+// searches nonces (and may roll the timestamp) on Block's header, then
+// hands the solved header back through Solve.
+type BlockTemplate struct {
+	Block         *Block
+	Height        int64
+	Target        *big.Int
+	MinTime       int64 // smallest timestamp the block may carry
+	CoinbaseValue int   // subsidy plus the fees of the selected transactions
+}
+
+// BlockSubsidy returns the coinbase reward for the next block on the tip
+func (bc *Blockchain) BlockSubsidy() int {
+	return int(bc.params.BlockSubsidy(bc.BestHeight() + 1))
+}
+
+// IssuedSupply returns the coins paid out by coinbases on the active chain.
+// A pruned node counts its pruned blocks from the total it kept of them.
+func (bc *Blockchain) IssuedSupply() int64 {
+	var total int64
+	err := bc.store.View(func(txn StoreTx) error {
+		total = issuedSupply(txn)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return total
+}
+
+// issuedSupply is IssuedSupply within an open transaction
+func issuedSupply(txn StoreTx) int64 {
+	total := readPrunedSupply(txn)
+	heights := txn.Bucket(heightIndexBucket)
+	tip, _ := readIndexEntry(txn, txn.Tip())
+
+	// genesis is never pruned
+	from := readPrunedTo(txn) + 1
+	if from == 1 {
+		from = 0
+	}
+	for h := from; h <= tip.Height; h++ {
+		total += coinbaseValue(txn.GetBlock(heights.Get(heightKey(h))))
+	}
+	return total
+}
+
+// coinbaseValue sums the outputs of the coinbase of b
+func coinbaseValue(b *Block) int64 {
+	var total int64
+	for _, t := range b.Transactions {
+		if t.IsCoinbase() {
+			for _, out := range t.Vout {
+				total += int64(out.Value)
+			}
+		}
+	}
+	return total
+}
+
+// NewBlockTemplate assembles a block on the current tip paying the coinbase
+// to payoutAddress, followed by the selected transactions
+func (bc *Blockchain) NewBlockTemplate(payoutAddress string, selected []*tx.Transaction) (*BlockTemplate, error) {
+	if _, ok := bc.engine.(*consensus.PoW); !ok {
+		return nil, ErrTemplateUnsupported
+	}
+
+	parent := bc.getBlock(bc.Tip())
+	height := bc.blockHeight(parent.Hash) + 1
+	fees, err := bc.newTxLookup(parent).fees(selected)
+	if err != nil {
+		return nil, err
+	}
+	value := int(bc.params.BlockSubsidy(height) + fees)
+	cbTx := tx.NewCoinbaseTX(payoutAddress, value, height, 0)
+	txs := append([]*tx.Transaction{cbTx}, selected...)
+
+	b := newTxBlock(txs, parent.Hash, 0)
+	b.Height = height
+	if err := checkBlockLimits(b, bc.params); err != nil {
+		return nil, err
+	}
+	minTime := bc.medianTimePast(parent) + 1
+	if b.Timestamp < minTime {
+		b.Timestamp = minTime
+	}
+	if err := bc.engine.Prepare(bc, b); err != nil {
+		return nil, err
+	}
+
+	return &BlockTemplate{
+		Block:         b,
+		Height:        height,
+		Target:        proof.Target(b.Bits),
+		MinTime:       minTime,
+		CoinbaseValue: value,
+	}, nil
+}
+
+// Solve returns a copy of the template block carrying the miner's solved
+// header. Only the fields a miner may change (timestamp, nonce) are taken
+// from h; the rest has to match the template. The result still has to go
+// through AcceptBlock.
+func (t *BlockTemplate) Solve(h *BlockHeader) (*Block, error) {
+	want := t.Block.Header()
+	if h.Version != want.Version || h.PrevBlock != want.PrevBlock ||
+		h.MerkleRoot != want.MerkleRoot || h.Bits != want.Bits {
+		return nil, errors.New("header does not belong to this template")
+	}
+
+	solved := *t.Block
+	solved.Timestamp = h.Timestamp
+	solved.Nonce = h.Nonce
+	solved.Hash = h.Hash()
+	return &solved, nil
+}
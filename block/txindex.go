@@ -0,0 +1,246 @@
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+// txIndexBucket maps the id of every transaction on the active chain to the
+// hash of its block followed by its 4-byte big-endian position in the
+// block. The index is optional: it is kept up to date while the bucket
+// exists.
+const txIndexBucket = "txindex"
+
+// ErrTxNotIndexed is returned when a transaction is not on the active chain
+var ErrTxNotIndexed = errors.New("transaction not found on the active chain")
+
+// TxResult is a transaction with the block that confirmed it
+type TxResult struct {
+	Transaction   *tx.Transaction
+	BlockHash     []byte
+	BlockHeight   int64
+	Position      int   // index of the transaction in its block
+	Confirmations int64 // 1 when the block is the tip
+}
+
+// txLocation is a decoded txindex entry
+type txLocation struct {
+	BlockHash []byte
+	Position  int
+}
+
+func (l txLocation) serialize() []byte {
+	buf := make([]byte, len(l.BlockHash)+4)
+	copy(buf, l.BlockHash)
+	binary.BigEndian.PutUint32(buf[len(l.BlockHash):], uint32(l.Position))
+	return buf
+}
+
+func deserializeTxLocation(d []byte) txLocation {
+	n := len(d) - 4
+	return txLocation{
+		BlockHash: append([]byte(nil), d[:n]...),
+		Position:  int(binary.BigEndian.Uint32(d[n:])),
+	}
+}
+
+// indexTransactions adds the transactions of a connected block, if the
+// index is enabled
+func indexTransactions(txn StoreTx, b *Block) error {
+	idx := txn.Bucket(txIndexBucket)
+	if idx == nil {
+		return nil
+	}
+	for i, t := range b.Transactions {
+		if err := idx.Put(t.ID, txLocation{BlockHash: b.Hash, Position: i}.serialize()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unindexTransactions removes the transactions of a disconnected block
+func unindexTransactions(txn StoreTx, b *Block) error {
+	idx := txn.Bucket(txIndexBucket)
+	if idx == nil {
+		return nil
+	}
+	for _, t := range b.Transactions {
+		if err := idx.Delete(t.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTxLocation looks a transaction up in the index. ok is false when the
+// index is disabled; found is false when it is enabled but has no entry.
+func readTxLocation(txn StoreTx, id []byte) (loc txLocation, found, ok bool) {
+	idx := txn.Bucket(txIndexBucket)
+	if idx == nil {
+		return txLocation{}, false, false
+	}
+	d := idx.Get(id)
+	if d == nil {
+		return txLocation{}, false, true
+	}
+	return deserializeTxLocation(d), true, true
+}
+
+// HasTxIndex reports whether the transaction index is enabled
+func (bc *Blockchain) HasTxIndex() bool {
+	enabled := false
+	err := bc.store.View(func(txn StoreTx) error {
+		enabled = txn.Bucket(txIndexBucket) != nil
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return enabled
+}
+
+// SetTxIndex turns the transaction index on, building it from the active
+// chain, or off, dropping it. The setting is stored in the database.
+func (bc *Blockchain) SetTxIndex(enabled bool) error {
+	return bc.setOptionalIndex(txIndexBucket, enabled, func(txn StoreTx, b *Block, height int64) error {
+		return indexTransactions(txn, b)
+	})
+}
+
+// setOptionalIndex creates and fills, or drops, the index bucket name. The
+// bucket existing is what turns the index on.
+func (bc *Blockchain) setOptionalIndex(name string, enabled bool, add func(txn StoreTx, b *Block, height int64) error) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	return bc.store.Update(func(txn StoreTx) error {
+		exists := txn.Bucket(name) != nil
+		switch {
+		case enabled && !exists && readPrunedTo(txn) > 0:
+			return fmt.Errorf("cannot build %s: blocks up to height %d are pruned", name, readPrunedTo(txn))
+		case enabled && !exists && name == txIndexBucket && bc.prune.Enabled():
+			return errors.New("the transaction index is not possible while pruning")
+		case enabled && !exists:
+			if _, err := txn.CreateBucket(name); err != nil {
+				return err
+			}
+			log.Printf("🗂️ Building %s", name)
+			return forEachActiveBlock(txn, func(b *Block, height int64) error {
+				return add(txn, b, height)
+			})
+		case !enabled && exists:
+			return txn.DeleteBucket(name)
+		}
+		return nil
+	})
+}
+
+// forEachActiveBlock calls fn for every block of the active chain, genesis
+// first
+func forEachActiveBlock(txn StoreTx, fn func(b *Block, height int64) error) error {
+	var chain [][]byte
+	for hash := txn.Tip(); len(hash) > 0; {
+		chain = append(chain, hash)
+		hash = txn.GetBlock(hash).PrevBlockHash
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if err := fn(txn.GetBlock(chain[i]), int64(len(chain)-1-i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindTransaction returns the transaction with id on the active chain and
+// its confirmation count. Without the transaction index it scans the chain
+// from the tip.
+func (bc *Blockchain) FindTransaction(id []byte) (*TxResult, error) {
+	tip := bc.Tip()
+	best := bc.blockHeight(tip)
+
+	var loc txLocation
+	var found, indexed bool
+	err := bc.store.View(func(txn StoreTx) error {
+		loc, found, indexed = readTxLocation(txn, id)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if !indexed {
+		b, pos := bc.scanTransaction(tip, id)
+		if b == nil {
+			return nil, ErrTxNotIndexed
+		}
+		loc = txLocation{BlockHash: b.Hash, Position: pos}
+	} else if !found {
+		return nil, ErrTxNotIndexed
+	}
+
+	b, err := bc.GetBlockByHash(loc.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	return &TxResult{
+		Transaction:   b.Transactions[loc.Position],
+		BlockHash:     b.Hash,
+		BlockHeight:   b.Height,
+		Position:      loc.Position,
+		Confirmations: best - b.Height + 1,
+	}, nil
+}
+
+// findIndexedTransaction answers findTransactionFrom from the index. ok is
+// false when the index is disabled or from is not on the active chain.
+func (bc *Blockchain) findIndexedTransaction(from, id []byte) (t *tx.Transaction, height int64, ok bool) {
+	fromHeight := bc.blockHeight(from)
+
+	var loc txLocation
+	var found bool
+	err := bc.store.View(func(txn StoreTx) error {
+		active := txn.Bucket(heightIndexBucket).Get(heightKey(fromHeight))
+		if !bytes.Equal(active, from) {
+			return nil
+		}
+		loc, found, ok = readTxLocation(txn, id)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	if !ok || !found {
+		return nil, 0, ok
+	}
+
+	b := bc.getBlock(loc.BlockHash)
+	height = bc.blockHeight(loc.BlockHash)
+	if height > fromHeight {
+		return nil, 0, true // confirmed after from
+	}
+	return b.Transactions[loc.Position], height, true
+}
+
+// scanTransaction walks back from hash to the block holding transaction id
+// and returns it with the position of the transaction, or nil
+func (bc *Blockchain) scanTransaction(hash, id []byte) (*Block, int) {
+	it := &BlockchainIterator{hash, bc.store}
+	for {
+		b := it.Next()
+		for i, t := range b.Transactions {
+			if bytes.Equal(t.ID, id) {
+				return b, i
+			}
+		}
+		if len(b.PrevBlockHash) == 0 {
+			return nil, 0
+		}
+	}
+}
@@ -0,0 +1,634 @@
+package block
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"math/big"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wire"
+)
+
+// A UTXO set snapshot holds the unspent outputs of the active chain at one
+// block, its base, together with the headers leading to it, so a new node
+// can start from it instead of replaying the whole history:
+//
+//	4 bytes   magic "GMUT"
+//	uint32    format version (txOutSetVersion)
+//	record    network | base hash | base height (int64) | issued supply (int64)
+//	then base height + 1 block records, genesis first and complete, the
+//	others with their header fields only (see EncodingVersion)
+//	int64     number of coins
+//	then one record per coin, in txid and output index order:
+//	          txid | output index (uint32) | height (int64) | coinbase (bool)
+//	          | value (int64) | pubkey hash
+//	32 bytes  TxOutSetInfo.Hash
+//
+// Records are prefixed with their uint32 length and use the encoding of
+// package wire. The hash is SHA-256 over the base hash and height, encoded
+// as in the header, followed by every coin record without its length
+// prefix. It depends only on the UTXO set and its base, so any two nodes
+// at the same block produce the same hash.
+const (
+	txOutSetMagic   = "GMUT"
+	txOutSetVersion = 1
+
+	// pendingTxOutSetKey in the metadata bucket describes a snapshot the
+	// chain was loaded from while the history below it is not validated.
+	// Until then the blocks below the base keep only their headers and
+	// count as pruned.
+	pendingTxOutSetKey = "txoutset"
+
+	// txOutSetProgressInterval is how many coins pass between progress logs
+	// of a dump or load
+	txOutSetProgressInterval = 100000
+)
+
+// ErrTxOutSetMismatch is returned when the validated history does not lead
+// to the UTXO set of the snapshot the chain was loaded from
+var ErrTxOutSetMismatch = errors.New("history does not match the loaded UTXO set snapshot")
+
+// ErrUntrustedTxOutSet is returned when loading a UTXO set snapshot whose
+// hash is not listed in Params.AssumeUTXO
+var ErrUntrustedTxOutSet = errors.New("UTXO set snapshot is not trusted")
+
+// TxOutSetInfo describes a UTXO set snapshot
+type TxOutSetInfo struct {
+	BaseHash []byte // block the snapshot was taken at
+	Height   int64  // height of the base block
+	Supply   int64  // coins paid out by coinbases up to the base block
+	Coins    int64  // unspent outputs
+	Amount   int64  // their total value
+	Hash     []byte // deterministic hash of the set, see the file format
+}
+
+func (info TxOutSetInfo) serialize() []byte {
+	var w wire.Writer
+	w.PutBytes(info.BaseHash)
+	w.PutInt64(info.Height)
+	w.PutInt64(info.Supply)
+	w.PutInt64(info.Coins)
+	w.PutInt64(info.Amount)
+	w.PutBytes(info.Hash)
+	return w.Bytes()
+}
+
+func deserializeTxOutSetInfo(d []byte) TxOutSetInfo {
+	r := wire.NewReader(d)
+	info := TxOutSetInfo{
+		BaseHash: r.Bytes(),
+		Height:   r.Int64(),
+		Supply:   r.Int64(),
+		Coins:    r.Int64(),
+		Amount:   r.Int64(),
+		Hash:     r.Bytes(),
+	}
+	if err := r.Done(); err != nil {
+		log.Panicf("corrupt %s metadata: %v", pendingTxOutSetKey, err)
+	}
+	return info
+}
+
+// newTxOutSetHash starts the hash of a snapshot at base
+func newTxOutSetHash(base []byte, height int64) hash.Hash {
+	var w wire.Writer
+	w.PutBytes(base)
+	w.PutInt64(height)
+	h := sha256.New()
+	h.Write(w.Bytes())
+	return h
+}
+
+// coinRecord encodes the chainstate entry stored under key as a snapshot
+// coin record
+func coinRecord(key []byte, entry utxoEntry) []byte {
+	txid, vout := splitOutpointKey(key)
+	var w wire.Writer
+	w.PutBytes(txid)
+	w.PutUint32(uint32(vout))
+	w.PutInt64(entry.Height)
+	w.PutBool(entry.Coinbase)
+	w.PutInt64(int64(entry.Output.Value))
+	w.PutBytes(entry.Output.PubKeyHash)
+	return w.Bytes()
+}
+
+// parseCoinRecord is the inverse of coinRecord
+func parseCoinRecord(d []byte) ([]byte, utxoEntry, error) {
+	r := wire.NewReader(d)
+	txid := r.Bytes()
+	vout := r.Uint32()
+	entry := utxoEntry{
+		Height:   r.Int64(),
+		Coinbase: r.Bool(),
+		Output:   tx.TXOutput{Value: int(r.Int64()), PubKeyHash: r.Bytes()},
+	}
+	if err := r.Done(); err != nil {
+		return nil, entry, err
+	}
+	if len(txid) == 0 || len(txid) > 255 || entry.Output.Value < 0 {
+		return nil, entry, errors.New("malformed coin")
+	}
+	return outpointKey(txid, int(vout)), entry, nil
+}
+
+// txOutSetInfo summarizes and hashes the UTXO set of txn at its tip
+func txOutSetInfo(ctx context.Context, txn StoreTx) (TxOutSetInfo, error) {
+	tip, _ := readIndexEntry(txn, txn.Tip())
+	info := TxOutSetInfo{
+		BaseHash: append([]byte(nil), txn.Tip()...),
+		Height:   tip.Height,
+		Supply:   issuedSupply(txn),
+	}
+
+	h := newTxOutSetHash(info.BaseHash, info.Height)
+	err := txn.Bucket(utxoBucket).ForEach(func(k, v []byte) error {
+		if info.Coins%txOutSetProgressInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		entry := deserializeUTXOEntry(v)
+		h.Write(coinRecord(k, entry))
+		info.Coins++
+		info.Amount += int64(entry.Output.Value)
+		return nil
+	})
+	info.Hash = h.Sum(nil)
+	return info, err
+}
+
+// TxOutSetInfo summarizes the UTXO set at the tip and computes its
+// snapshot hash, without writing a snapshot
+func (bc *Blockchain) TxOutSetInfo(ctx context.Context) (TxOutSetInfo, error) {
+	var info TxOutSetInfo
+	err := bc.store.View(func(txn StoreTx) (err error) {
+		info, err = txOutSetInfo(ctx, txn)
+		return err
+	})
+	return info, err
+}
+
+// DumpTxOutSet writes a snapshot of the UTXO set at the current tip to w.
+// It works on pruned chains too, as it needs only headers and the UTXO
+// set.
+func (bc *Blockchain) DumpTxOutSet(ctx context.Context, w io.Writer) (TxOutSetInfo, error) {
+	var info TxOutSetInfo
+	buf := bufio.NewWriter(w)
+	err := bc.store.View(func(txn StoreTx) error {
+		var err error
+		if info, err = txOutSetInfo(ctx, txn); err != nil {
+			return err
+		}
+
+		var head wire.Writer
+		head.PutUint32(txOutSetVersion)
+		if _, err := buf.Write(append([]byte(txOutSetMagic), head.Bytes()...)); err != nil {
+			return err
+		}
+		var meta wire.Writer
+		meta.PutBytes([]byte(bc.params.Name))
+		meta.PutBytes(info.BaseHash)
+		meta.PutInt64(info.Height)
+		meta.PutInt64(info.Supply)
+		if err := writeRecord(buf, meta.Bytes()); err != nil {
+			return err
+		}
+
+		heights := txn.Bucket(heightIndexBucket)
+		for h := int64(0); h <= info.Height; h++ {
+			b := txn.GetBlock(heights.Get(heightKey(h)))
+			if h > 0 {
+				b.Data, b.Transactions = nil, nil
+			}
+			if err := writeRecord(buf, b.Serialize()); err != nil {
+				return err
+			}
+		}
+
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(info.Coins))
+		if _, err := buf.Write(n[:]); err != nil {
+			return err
+		}
+		var written int64
+		err = txn.Bucket(utxoBucket).ForEach(func(k, v []byte) error {
+			if err := writeRecord(buf, coinRecord(k, deserializeUTXOEntry(v))); err != nil {
+				return err
+			}
+			written++
+			if written%txOutSetProgressInterval == 0 {
+				log.Printf("📤 Dumped %d/%d coins", written, info.Coins)
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := buf.Write(info.Hash); err != nil {
+			return err
+		}
+		return buf.Flush()
+	})
+	return info, err
+}
+
+// LoadTxOutSet starts a chain in store from a snapshot written by
+// DumpTxOutSet. The store must be empty or hold only the genesis block of
+// the snapshot. The chain it returns has its tip at the snapshot base and
+// answers balance queries and validates new blocks straight away. The
+// headers below the base are checked for linkage, proof of work and
+// checkpoints, and the coins against the snapshot hash. Everything else
+// about the history is taken on trust until ValidateTxOutSet replays it;
+// until then the chain counts as pruned up to the base. That trust comes
+// from params.AssumeUTXO: a snapshot whose hash is not listed there for its
+// height fails with ErrUntrustedTxOutSet. The coins must also be sorted and
+// unique, and add up to no more than the supply the base height allows. As
+// with NewWithGenesis, the caller closes store if loading fails.
+func LoadTxOutSet(ctx context.Context, store ChainStore, params *chaincfg.Params, r io.Reader) (*Blockchain, TxOutSetInfo, error) {
+	var info TxOutSetInfo
+	r = bufio.NewReader(r)
+
+	head := make([]byte, len(txOutSetMagic)+4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, info, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if string(head[:len(txOutSetMagic)]) != txOutSetMagic {
+		return nil, info, errors.New("not a UTXO set snapshot")
+	}
+	if v := binary.LittleEndian.Uint32(head[len(txOutSetMagic):]); v != txOutSetVersion {
+		return nil, info, fmt.Errorf("unsupported snapshot version %d", v)
+	}
+	d, err := readRecord(r)
+	if err != nil {
+		return nil, info, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	meta := wire.NewReader(d)
+	network := string(meta.Bytes())
+	info.BaseHash = meta.Bytes()
+	info.Height = meta.Int64()
+	info.Supply = meta.Int64()
+	if err := meta.Done(); err != nil {
+		return nil, info, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if network != params.Name {
+		return nil, info, fmt.Errorf("snapshot is for network %q, not %q", network, params.Name)
+	}
+	if info.Height < 0 {
+		return nil, info, fmt.Errorf("snapshot base height %d", info.Height)
+	}
+	trusted, ok := params.TrustedTxOutSet(info.Height)
+	if !ok {
+		return nil, info, fmt.Errorf("%w: no snapshot hash is trusted at height %d", ErrUntrustedTxOutSet, info.Height)
+	}
+	if limit := params.TotalSupply(info.Height); info.Supply < 0 || info.Supply > limit {
+		return nil, info, fmt.Errorf("snapshot claims a supply of %d, outside 0..%d at height %d", info.Supply, limit, info.Height)
+	}
+
+	genesis, err := readSnapshotBlock(r)
+	if err != nil {
+		return nil, info, err
+	}
+	bc, err := NewWithGenesis(store, params, genesis)
+	if err != nil {
+		return nil, info, err
+	}
+	if bc.BestHeight() > 0 {
+		return nil, info, errors.New("the chain already has blocks; load the snapshot into an empty data directory")
+	}
+	if !bytes.Equal(bc.Tip(), genesis.Hash) {
+		return nil, info, fmt.Errorf("snapshot starts from genesis %x, this chain from %x", genesis.Hash, bc.Tip())
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	err = store.Update(func(txn StoreTx) error {
+		if err := loadSnapshotHeaders(ctx, bc, txn, r, genesis, info); err != nil {
+			return err
+		}
+		if err := loadSnapshotCoins(ctx, txn, r, params, &info); err != nil {
+			return err
+		}
+		if got := hex.EncodeToString(info.Hash); got != trusted {
+			return fmt.Errorf("%w: it hashes to %s, the trusted hash at height %d is %s", ErrUntrustedTxOutSet, got, info.Height, trusted)
+		}
+
+		if info.Height == 0 {
+			return nil
+		}
+		m := txn.Bucket(metadataBucket)
+		if err := m.Put([]byte(prunedToKey), heightKey(info.Height)); err != nil {
+			return err
+		}
+		if err := m.Put([]byte(prunedSupplyKey), heightKey(info.Supply)); err != nil {
+			return err
+		}
+		if err := m.Put([]byte(pendingTxOutSetKey), info.serialize()); err != nil {
+			return err
+		}
+		return txn.SetTip(info.BaseHash)
+	})
+	if err != nil {
+		return nil, info, err
+	}
+	if info.Height > 0 {
+		bc.tip = info.BaseHash
+	}
+	return bc, info, nil
+}
+
+// readSnapshotBlock reads the next block record of a snapshot
+func readSnapshotBlock(r io.Reader) (*Block, error) {
+	d, err := readRecord(r)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, errors.New("snapshot ends inside its headers")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Deserialize(d)
+}
+
+// loadSnapshotHeaders stores the header-only blocks from genesis up to the
+// snapshot base as the active chain
+func loadSnapshotHeaders(ctx context.Context, bc *Blockchain, txn StoreTx, r io.Reader, genesis *Block, info TxOutSetInfo) error {
+	idx := txn.Bucket(blockIndexBucket)
+	heights := txn.Bucket(heightIndexBucket)
+	work := new(big.Int).Set(bc.engine.Work(genesis))
+
+	parent := genesis
+	for h := int64(1); h <= info.Height; h++ {
+		if h%bootstrapProgressInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			log.Printf("📥 Loaded %d/%d snapshot headers", h, info.Height)
+		}
+		b, err := readSnapshotBlock(r)
+		if err != nil {
+			return err
+		}
+		if err := checkSnapshotHeader(bc, b, parent, h); err != nil {
+			return fmt.Errorf("snapshot header %d: %w", h, err)
+		}
+
+		work = new(big.Int).Add(work, bc.engine.Work(b))
+		entry := blockIndexEntry{Height: h, ChainWork: work}
+		if err := txn.PutBlock(b); err != nil {
+			return err
+		}
+		if err := idx.Put(b.Hash, entry.serialize()); err != nil {
+			return err
+		}
+		if err := heights.Put(heightKey(h), b.Hash); err != nil {
+			return err
+		}
+		parent = b
+	}
+	if !bytes.Equal(parent.Hash, info.BaseHash) {
+		return fmt.Errorf("snapshot headers end at %x, not at its base %x", parent.Hash, info.BaseHash)
+	}
+	return nil
+}
+
+// checkSnapshotHeader applies the header checks that need no chain
+// context: difficulty and timestamp rules wait for ValidateTxOutSet
+func checkSnapshotHeader(bc *Blockchain, b, parent *Block, height int64) error {
+	if len(b.Data) != 0 || len(b.Transactions) != 0 {
+		return errors.New("block carries a body")
+	}
+	if !bytes.Equal(b.PrevBlockHash, parent.Hash) {
+		return fmt.Errorf("previous block hash %x does not link to %x", b.PrevBlockHash, parent.Hash)
+	}
+	if b.Height != height {
+		return ruleError(ErrBadHeight, "block claims height %d", b.Height)
+	}
+	if header := b.Header(); !bytes.Equal(header.Hash(), b.Hash) {
+		return ruleError(ErrBadHash, "header hashes to %x", header.Hash())
+	}
+	if bc.params.Consensus == chaincfg.ProofOfWork && !proof.NewProofOfWork(b).Validate() {
+		return ruleError(ErrHighHash, "hash is above the target for %d bits", b.Bits)
+	}
+	if cp, ok := bc.params.Checkpoint(height); ok && hex.EncodeToString(b.Hash) != cp.Hash {
+		return ruleError(ErrBadCheckpoint, "block %x does not match checkpoint %s", b.Hash, cp.Hash)
+	}
+	return nil
+}
+
+// loadSnapshotCoins fills the UTXO set from the coin records of a snapshot
+// and checks them against its hash, filling in the coin count, amount and
+// hash of info. Coins have to come in strictly ascending outpoint order,
+// so none is repeated, and their values may not add up to more than the
+// supply of info.
+func loadSnapshotCoins(ctx context.Context, txn StoreTx, r io.Reader, params *chaincfg.Params, info *TxOutSetInfo) error {
+	var n [8]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return errors.New("snapshot ends before its coins")
+	}
+	count := int64(binary.LittleEndian.Uint64(n[:]))
+
+	utxos := txn.Bucket(utxoBucket)
+	h := newTxOutSetHash(info.BaseHash, info.Height)
+	var amount int64
+	var prev []byte
+	for i := int64(0); i < count; i++ {
+		if (i+1)%txOutSetProgressInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			log.Printf("📥 Loaded %d/%d coins", i+1, count)
+		}
+		d, err := readRecord(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("snapshot ends after %d of %d coins", i, count)
+		}
+		if err != nil {
+			return err
+		}
+		key, entry, err := parseCoinRecord(d)
+		if err != nil {
+			return fmt.Errorf("coin %d: %w", i, err)
+		}
+		if entry.Height > info.Height {
+			return fmt.Errorf("coin %d was created at height %d, above the snapshot base", i, entry.Height)
+		}
+		if bytes.Compare(key, prev) <= 0 {
+			return fmt.Errorf("coin %d is out of order or repeated", i)
+		}
+		if int64(entry.Output.Value) > params.MaxSupply {
+			return fmt.Errorf("coin %d is worth %d, more than MaxSupply", i, entry.Output.Value)
+		}
+		var ok bool
+		if amount, ok = addMoney(amount, int64(entry.Output.Value), params.MaxSupply); !ok || amount > info.Supply {
+			return fmt.Errorf("coins up to %d add up to more than the issued supply of %d", i, info.Supply)
+		}
+		if err := utxos.Put(key, entry.serialize()); err != nil {
+			return err
+		}
+		h.Write(d)
+		prev = key
+	}
+
+	want := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, want); err != nil {
+		return errors.New("snapshot ends before its hash")
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		return fmt.Errorf("snapshot coins hash to %x, the file says %x", got, want)
+	}
+	info.Coins, info.Amount, info.Hash = count, amount, want
+	return nil
+}
+
+// PendingTxOutSet returns the snapshot the chain was loaded from while the
+// history below it has not been validated yet
+func (bc *Blockchain) PendingTxOutSet() (TxOutSetInfo, bool) {
+	var info TxOutSetInfo
+	var ok bool
+	err := bc.store.View(func(txn StoreTx) error {
+		if meta := txn.Bucket(metadataBucket); meta != nil {
+			if d := meta.Get([]byte(pendingTxOutSetKey)); d != nil {
+				info, ok = deserializeTxOutSetInfo(d), true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return info, ok
+}
+
+// ValidateTxOutSet checks the snapshot the chain was loaded from against
+// the real history: it replays the blocks of br, a bootstrap file of the
+// same chain, in a separate chain kept in scratch, which may be reused to
+// resume an interrupted run. If the replay reaches the snapshot base with
+// the same UTXO set hash and supply, the block bodies and undo data below
+// the base are copied over and the chain stops counting as pruned; a node
+// that prunes keeps them pruned instead. A history that leads elsewhere returns an error
+// wrapping ErrTxOutSetMismatch. The chain keeps accepting blocks while this
+// runs.
+func (bc *Blockchain) ValidateTxOutSet(ctx context.Context, br *BootstrapReader, scratch ChainStore) error {
+	pending, ok := bc.PendingTxOutSet()
+	if !ok {
+		return errors.New("the chain was not loaded from a UTXO set snapshot, or it is validated already")
+	}
+	if br.Network != bc.params.Name {
+		return fmt.Errorf("bootstrap file is for network %q, not %q", br.Network, bc.params.Name)
+	}
+	genesis, err := bc.GetBlockByHeight(0)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(br.Genesis.Hash, genesis.Hash) {
+		return fmt.Errorf("bootstrap file starts from genesis %x, this chain from %x", br.Genesis.Hash, genesis.Hash)
+	}
+
+	history, err := NewWithGenesis(scratch, bc.params, br.Genesis)
+	if err != nil {
+		return err
+	}
+	// the snapshot headers tell which history blocks lead to the
+	// assume-valid block
+	history.headers = bc
+	log.Printf("🔍 Validating the history below the UTXO set snapshot at height %d", pending.Height)
+	for history.BestHeight() < pending.Height {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b, err := br.Next()
+		if err == io.EOF {
+			return fmt.Errorf("bootstrap file ends at height %d, below the snapshot base at %d", history.BestHeight(), pending.Height)
+		}
+		if err != nil {
+			return err
+		}
+		if history.getBlock(b.Hash) != nil {
+			continue
+		}
+		if err := history.AcceptBlock(b); err != nil {
+			return fmt.Errorf("history block %d (%x): %w", b.Height, b.Hash, err)
+		}
+		if b.Height%bootstrapProgressInterval == 0 {
+			log.Printf("🔍 Validated history up to height %d/%d", b.Height, pending.Height)
+		}
+	}
+
+	got, err := history.TxOutSetInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got.BaseHash, pending.BaseHash) {
+		return fmt.Errorf("%w: the history reaches %x at height %d, the snapshot base is %x",
+			ErrTxOutSetMismatch, got.BaseHash, got.Height, pending.BaseHash)
+	}
+	if !bytes.Equal(got.Hash, pending.Hash) || got.Supply != pending.Supply {
+		return fmt.Errorf("%w: the history gives UTXO set hash %x and supply %d, the snapshot %x and %d",
+			ErrTxOutSetMismatch, got.Hash, got.Supply, pending.Hash, pending.Supply)
+	}
+	if err := bc.adoptHistory(scratch, pending); err != nil {
+		return err
+	}
+	log.Printf("✅ History validated: the UTXO set snapshot at height %d matches", pending.Height)
+	return nil
+}
+
+// adoptHistory copies the validated blocks and undo data below the pending
+// snapshot base from scratch, adds them to the optional indexes that are
+// enabled, and forgets the snapshot
+func (bc *Blockchain) adoptHistory(scratch ChainStore, pending TxOutSetInfo) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	return bc.store.Update(func(txn StoreTx) error {
+		meta := txn.Bucket(metadataBucket)
+		if bc.prune.Enabled() || readPrunedTo(txn) > pending.Height {
+			// pruning took over where the snapshot left off
+			return meta.Delete([]byte(pendingTxOutSetKey))
+		}
+
+		return scratch.View(func(stx StoreTx) error {
+			heights := txn.Bucket(heightIndexBucket)
+			undo := txn.Bucket(undoBucket)
+			for h := int64(1); h <= pending.Height; h++ {
+				hash := heights.Get(heightKey(h))
+				if !bytes.Equal(hash, stx.Bucket(heightIndexBucket).Get(heightKey(h))) {
+					return fmt.Errorf("%w: block %d differs from the history", ErrTxOutSetMismatch, h)
+				}
+				b := stx.GetBlock(hash)
+				if err := txn.PutBlock(b); err != nil {
+					return err
+				}
+				if err := indexTransactions(txn, b); err != nil {
+					return err
+				}
+				if err := indexAddresses(txn, b, h); err != nil {
+					return err
+				}
+				if err := undo.Put(hash, stx.Bucket(undoBucket).Get(hash)); err != nil {
+					return err
+				}
+			}
+
+			for _, key := range []string{pendingTxOutSetKey, prunedToKey, prunedSupplyKey} {
+				if err := meta.Delete([]byte(key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
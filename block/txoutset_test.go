@@ -0,0 +1,182 @@
+package block
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+)
+
+// bootstrapFile exports the active chain of bc
+func bootstrapFile(t *testing.T, bc *Blockchain) []byte {
+	t.Helper()
+	var file bytes.Buffer
+	if _, err := bc.ExportChain(context.Background(), &file, false); err != nil {
+		t.Fatal(err)
+	}
+	return file.Bytes()
+}
+
+func TestTxOutSetRoundTrip(t *testing.T) {
+	params := testParams()
+	src := newTestChain(t, params)
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, src, tipBlock(t, src), w.Address())
+	if err := src.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+	b2 := newTestBlock(t, src, b1, testAddress, spendTx(w, cb, []int{0}, tx.NewTXOutput(1, w.Address()), tx.NewTXOutput(cb.Vout[0].Value-1, testAddress)))
+	if err := src.AcceptBlock(b2); err != nil {
+		t.Fatal(err)
+	}
+	acceptTestBlocks(t, src, b2, 2)
+
+	// a chain that shares the genesis but not the history
+	other := newTestChainFrom(t, src, params)
+	acceptTestBlocks(t, other, tipBlock(t, other), 4)
+
+	var snapshot bytes.Buffer
+	want, err := src.DumpTxOutSet(context.Background(), &snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := bootstrapFile(t, src)
+
+	load := func(params *chaincfg.Params) (*Blockchain, error) {
+		bc, _, err := LoadTxOutSet(context.Background(), NewMemStore(), params, bytes.NewReader(snapshot.Bytes()))
+		if err == nil {
+			t.Cleanup(bc.Close)
+		}
+		return bc, err
+	}
+	if _, err := load(params); !errors.Is(err, ErrUntrustedTxOutSet) {
+		t.Fatalf("untrusted snapshot: got %v, want ErrUntrustedTxOutSet", err)
+	}
+
+	trusted := *params
+	trusted.AssumeUTXO = []chaincfg.Checkpoint{{Height: want.Height, Hash: hex.EncodeToString(want.Hash)}}
+	bc, err := load(&trusted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := bc.TxOutSetInfo(context.Background()); !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded UTXO set %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(utxoSet(t, bc), utxoSet(t, src)) {
+		t.Error("the loaded UTXO set differs from the dumped one")
+	}
+	if _, ok := bc.PendingTxOutSet(); !ok {
+		t.Fatal("the history of a loaded snapshot is not pending")
+	}
+
+	// a history leading elsewhere is refused, and the right one adopted
+	br, err := NewBootstrapReader(bytes.NewReader(bootstrapFile(t, other)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ValidateTxOutSet(context.Background(), br, NewMemStore()); !errors.Is(err, ErrTxOutSetMismatch) {
+		t.Errorf("another history: got %v, want ErrTxOutSetMismatch", err)
+	}
+	br, err = NewBootstrapReader(bytes.NewReader(history))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ValidateTxOutSet(context.Background(), br, NewMemStore()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bc.PendingTxOutSet(); ok || bc.IsPruned() {
+		t.Error("the chain still waits for its history after adopting it")
+	}
+	if _, err := bc.Verify(VerifyTransactions); err != nil {
+		t.Error(err)
+	}
+	if got := bc.IssuedSupply(); got != want.Supply {
+		t.Errorf("issued supply %d, want %d", got, want.Supply)
+	}
+
+	// and the chain goes on like the one it was taken from
+	b5 := newTestBlock(t, src, tipBlock(t, src), testAddress)
+	for _, c := range []*Blockchain{src, bc} {
+		if err := c.AcceptBlock(b5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(utxoSet(t, bc), utxoSet(t, src)) {
+		t.Error("the UTXO sets parted after the next block")
+	}
+}
+
+func TestValidateTxOutSetIndexesHistory(t *testing.T) {
+	params := testParams()
+	src := newTestChain(t, params)
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, src, tipBlock(t, src), w.Address())
+	if err := src.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+	spend := spendTx(w, cb, []int{0}, tx.NewTXOutput(cb.Vout[0].Value, testAddress))
+	if err := src.AcceptBlock(newTestBlock(t, src, b1, testAddress, spend)); err != nil {
+		t.Fatal(err)
+	}
+
+	var snapshot bytes.Buffer
+	info, err := src.DumpTxOutSet(context.Background(), &snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted := *params
+	trusted.AssumeUTXO = []chaincfg.Checkpoint{{Height: info.Height, Hash: hex.EncodeToString(info.Hash)}}
+
+	// both indexes are enabled on the empty data directory
+	genesis, err := src.GetBlockByHeight(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemStore()
+	indexed, err := NewWithGenesis(store, &trusted, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := indexed.SetTxIndex(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexed.SetAddrIndex(true); err != nil {
+		t.Fatal(err)
+	}
+
+	bc, _, err := LoadTxOutSet(context.Background(), store, &trusted, &snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(bc.Close)
+	br, err := NewBootstrapReader(bytes.NewReader(bootstrapFile(t, src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ValidateTxOutSet(context.Background(), br, NewMemStore()); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := bc.FindTransaction(spend.ID)
+	if err != nil {
+		t.Fatalf("transaction of the adopted history: %v", err)
+	}
+	if res.BlockHeight != 2 || res.Position != 1 {
+		t.Errorf("found the spend at height %d position %d, want 2 and 1", res.BlockHeight, res.Position)
+	}
+	history, total, err := bc.AddressHistory(wallet.PubKeyHash(w.PubKey), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(history) != 2 {
+		t.Errorf("address history of the adopted range has %d of %d entries, want 2", len(history), total)
+	}
+}
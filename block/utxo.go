@@ -0,0 +1,385 @@
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wire"
+)
+
+const (
+	// utxoBucket holds every unspent output of the active chain, keyed by
+	// txid || output index
+	utxoBucket = "chainstate"
+
+	// undoBucket maps a connected block hash to the outputs it spent, so a
+	// reorg can put them back
+	undoBucket = "blockundo"
+)
+
+// utxoEntry is an unspent output with what the spending rules need to know
+// about the transaction that created it
+type utxoEntry struct {
+	Height   int64
+	Coinbase bool
+	Output   tx.TXOutput
+}
+
+// utxoEntryMinSize is the length of a serialized entry with an empty
+// pubkey hash
+const utxoEntryMinSize = 17
+
+// serialize lays an entry out as height (8) | coinbase flag (1) | value (8)
+// | pubkey hash, integers big-endian
+func (e utxoEntry) serialize() []byte {
+	buf := make([]byte, utxoEntryMinSize, utxoEntryMinSize+len(e.Output.PubKeyHash))
+	binary.BigEndian.PutUint64(buf[0:8], uint64(e.Height))
+	if e.Coinbase {
+		buf[8] = 1
+	}
+	binary.BigEndian.PutUint64(buf[9:17], uint64(e.Output.Value))
+	return append(buf, e.Output.PubKeyHash...)
+}
+
+func deserializeUTXOEntry(d []byte) utxoEntry {
+	return utxoEntry{
+		Height:   int64(binary.BigEndian.Uint64(d[0:8])),
+		Coinbase: d[8] == 1,
+		Output: tx.TXOutput{
+			Value:      int(int64(binary.BigEndian.Uint64(d[9:17]))),
+			PubKeyHash: append([]byte(nil), d[17:]...),
+		},
+	}
+}
+
+// outpointKey is the chainstate key of output vout of txid
+func outpointKey(txid []byte, vout int) []byte {
+	key := make([]byte, len(txid)+4)
+	copy(key, txid)
+	binary.BigEndian.PutUint32(key[len(txid):], uint32(vout))
+	return key
+}
+
+// splitOutpointKey is the inverse of outpointKey
+func splitOutpointKey(key []byte) ([]byte, int) {
+	n := len(key) - 4
+	return key[:n], int(binary.BigEndian.Uint32(key[n:]))
+}
+
+// spentOutput is one undo record: the key and entry a block removed
+type spentOutput struct {
+	key   []byte
+	entry utxoEntry
+}
+
+// serializeUndo writes the record count (uint32) followed by every record
+// as key | entry, both byte strings of package wire, in the order
+// connectBlock spent them
+func serializeUndo(spent []spentOutput) []byte {
+	var w wire.Writer
+	w.PutUint32(uint32(len(spent)))
+	for _, s := range spent {
+		w.PutBytes(s.key)
+		w.PutBytes(s.entry.serialize())
+	}
+	return w.Bytes()
+}
+
+func deserializeUndo(d []byte) ([]spentOutput, error) {
+	r := wire.NewReader(d)
+	n := r.Count(4 + 4 + utxoEntryMinSize)
+	spent := make([]spentOutput, 0, n)
+	for i := 0; i < n && r.Err() == nil; i++ {
+		key, entry := r.Bytes(), r.Bytes()
+		if r.Err() == nil && len(entry) < utxoEntryMinSize {
+			r.Fail(fmt.Errorf("undo record %d is %d bytes", i, len(entry)))
+		}
+		if r.Err() == nil {
+			spent = append(spent, spentOutput{key: key, entry: deserializeUTXOEntry(entry)})
+		}
+	}
+	if err := r.Done(); err != nil {
+		return nil, err
+	}
+	return spent, nil
+}
+
+// connectBlock makes b the block at height of the active chain: it spends
+// the inputs and adds the outputs of b to the chainstate, records what it
+// spent for disconnectBlock and indexes b by height (and in the optional
+// indexes). Spending an output that is missing or already spent, or
+// creating one that is still unspent, fails the whole transaction.
+func connectBlock(txn StoreTx, b *Block, height int64) error {
+	utxos := txn.Bucket(utxoBucket)
+	if err := txn.Bucket(heightIndexBucket).Put(heightKey(height), b.Hash); err != nil {
+		return err
+	}
+
+	var spent []spentOutput
+	for _, t := range b.Transactions {
+		if !t.IsCoinbase() {
+			for _, in := range t.Vin {
+				key := outpointKey(in.Txid, in.Vout)
+				d := utxos.Get(key)
+				if d == nil {
+					return ruleError(ErrMissingTxOut,
+						"transaction %x spends %x:%d which is missing or already spent", t.ID, in.Txid, in.Vout)
+				}
+				spent = append(spent, spentOutput{key: key, entry: deserializeUTXOEntry(d)})
+				if err := utxos.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+
+		for i, out := range t.Vout {
+			key := outpointKey(t.ID, i)
+			if utxos.Get(key) != nil {
+				return ruleError(ErrOverwriteTx,
+					"transaction %x would overwrite unspent output %x:%d", t.ID, t.ID, i)
+			}
+			entry := utxoEntry{Height: height, Coinbase: t.IsCoinbase(), Output: out}
+			if err := utxos.Put(key, entry.serialize()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := indexTransactions(txn, b); err != nil {
+		return err
+	}
+	if err := indexAddresses(txn, b, height); err != nil {
+		return err
+	}
+	return txn.Bucket(undoBucket).Put(b.Hash, serializeUndo(spent))
+}
+
+// disconnectBlock undoes connectBlock for the current tip b at height. The
+// transactions are undone last to first, each by deleting its outputs and
+// then putting back what it spent, so outputs created and spent within b
+// do not come back.
+func disconnectBlock(txn StoreTx, b *Block, height int64) error {
+	utxos := txn.Bucket(utxoBucket)
+	undo := txn.Bucket(undoBucket)
+	if err := txn.Bucket(heightIndexBucket).Delete(heightKey(height)); err != nil {
+		return err
+	}
+	if err := unindexTransactions(txn, b); err != nil {
+		return err
+	}
+	if err := unindexAddresses(txn, b, height); err != nil {
+		return err
+	}
+
+	spent, err := deserializeUndo(undo.Get(b.Hash))
+	if err != nil {
+		return fmt.Errorf("undo data of block %x: %w", b.Hash, err)
+	}
+	for i := len(b.Transactions) - 1; i >= 0; i-- {
+		t := b.Transactions[i]
+		for j := range t.Vout {
+			if err := utxos.Delete(outpointKey(t.ID, j)); err != nil {
+				return err
+			}
+		}
+		if t.IsCoinbase() {
+			continue
+		}
+
+		// connectBlock recorded the inputs in order, so t's are last
+		n := len(spent) - len(t.Vin)
+		if n < 0 {
+			return fmt.Errorf("undo data of block %x is missing inputs of transaction %x", b.Hash, t.ID)
+		}
+		for _, s := range spent[n:] {
+			if err := utxos.Put(s.key, s.entry.serialize()); err != nil {
+				return err
+			}
+		}
+		spent = spent[:n]
+	}
+	if len(spent) != 0 {
+		return fmt.Errorf("undo data of block %x has %d records no transaction spent", b.Hash, len(spent))
+	}
+	return undo.Delete(b.Hash)
+}
+
+// switchChainState moves the chainstate from the chain ending at oldTip to
+// the one ending at newTip: blocks only on the old chain are disconnected,
+// tip first, then blocks only on the new chain are connected from the fork
+// point up.
+func switchChainState(txn StoreTx, oldTip, newTip []byte) error {
+	load := func(hash []byte) (*Block, int64) {
+		entry, _ := readIndexEntry(txn, hash)
+		return txn.GetBlock(hash), entry.Height
+	}
+
+	// after the walk back newHeight is the height of the fork point
+	oldBlock, oldHeight := load(oldTip)
+	newBlock, newHeight := load(newTip)
+
+	var detach, attach []*Block
+	for newHeight > oldHeight {
+		attach = append(attach, newBlock)
+		newBlock, newHeight = load(newBlock.PrevBlockHash)
+	}
+	for oldHeight > newHeight {
+		detach = append(detach, oldBlock)
+		oldBlock, oldHeight = load(oldBlock.PrevBlockHash)
+	}
+	for !bytes.Equal(oldBlock.Hash, newBlock.Hash) {
+		detach = append(detach, oldBlock)
+		attach = append(attach, newBlock)
+		oldBlock, oldHeight = load(oldBlock.PrevBlockHash)
+		newBlock, newHeight = load(newBlock.PrevBlockHash)
+	}
+
+	// pruned blocks have neither the transactions nor the undo data to be
+	// disconnected
+	if prunedTo := readPrunedTo(txn); len(detach) > 0 && newHeight < prunedTo {
+		return ruleError(ErrReorgTooDeep,
+			"reorg to %x would disconnect blocks down to height %d, which are pruned up to %d", newTip, newHeight+1, prunedTo)
+	}
+
+	for i, b := range detach {
+		if err := disconnectBlock(txn, b, newHeight+int64(len(detach)-i)); err != nil {
+			return err
+		}
+	}
+	for i := len(attach) - 1; i >= 0; i-- {
+		if err := connectBlock(txn, attach[i], newHeight+int64(len(attach)-i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chainStateBuckets are derived from the active chain by connectBlock
+var chainStateBuckets = []string{utxoBucket, undoBucket, heightIndexBucket}
+
+// optionalIndexBuckets are also maintained by connectBlock, while they exist
+var optionalIndexBuckets = []string{txIndexBucket, addrIndexBucket}
+
+// hasChainState reports whether every chain state bucket exists
+func hasChainState(txn StoreTx) bool {
+	for _, name := range chainStateBuckets {
+		if txn.Bucket(name) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// buildChainState (re)creates the chain state buckets, and the optional
+// indexes that are enabled, by connecting the active chain from genesis
+func buildChainState(txn StoreTx) error {
+	names := append([]string(nil), chainStateBuckets...)
+	for _, name := range optionalIndexBuckets {
+		if txn.Bucket(name) != nil {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		if txn.Bucket(name) != nil {
+			if err := txn.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if _, err := txn.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+
+	return forEachActiveBlock(txn, func(b *Block, height int64) error {
+		if height > 0 && height%10000 == 0 {
+			log.Printf("🗂️ Reindexed %d blocks", height)
+		}
+		return connectBlock(txn, b, height)
+	})
+}
+
+// Reindex rebuilds the UTXO set, the height index and the enabled optional
+// indexes from the stored blocks of the active chain
+func (bc *Blockchain) Reindex() error {
+	if pruned := bc.PruneHeight(); pruned > 0 {
+		return fmt.Errorf("cannot reindex: blocks up to height %d are pruned", pruned)
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	return bc.store.Update(buildChainState)
+}
+
+// findUTXO looks output vout of txid up in the UTXO set
+func (bc *Blockchain) findUTXO(txid []byte, vout int) (utxoEntry, bool) {
+	var entry utxoEntry
+	var ok bool
+
+	err := bc.store.View(func(txn StoreTx) error {
+		if d := txn.Bucket(utxoBucket).Get(outpointKey(txid, vout)); d != nil {
+			entry, ok = deserializeUTXOEntry(d), true
+		}
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return entry, ok
+}
+
+// FindUTXO returns every unspent output locked to pubKeyHash
+func (bc *Blockchain) FindUTXO(pubKeyHash []byte) []tx.TXOutput {
+	var outputs []tx.TXOutput
+
+	err := bc.store.View(func(txn StoreTx) error {
+		return txn.Bucket(utxoBucket).ForEach(func(k, v []byte) error {
+			if entry := deserializeUTXOEntry(v); bytes.Equal(entry.Output.PubKeyHash, pubKeyHash) {
+				outputs = append(outputs, entry.Output)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return outputs
+}
+
+// FindSpendableOutputs collects unspent outputs locked to pubKeyHash until
+// they add up to at least amount. It returns their total and the output
+// indexes to spend keyed by hex txid. Coinbase outputs that are not mature
+// yet for the next block are left out. The total is below amount when
+// pubKeyHash does not own enough.
+func (bc *Blockchain) FindSpendableOutputs(pubKeyHash []byte, amount int) (int, map[string][]int) {
+	unspent := make(map[string][]int)
+	accumulated := 0
+	nextHeight := bc.BestHeight() + 1
+
+	err := bc.store.View(func(txn StoreTx) error {
+		c := txn.Bucket(utxoBucket).Cursor()
+		for k, v := c.First(); k != nil && accumulated < amount; k, v = c.Next() {
+			entry := deserializeUTXOEntry(v)
+			if !bytes.Equal(entry.Output.PubKeyHash, pubKeyHash) {
+				continue
+			}
+			if entry.Coinbase && nextHeight-entry.Height < bc.params.CoinbaseMaturity {
+				continue
+			}
+
+			txid, vout := splitOutpointKey(k)
+			id := hex.EncodeToString(txid)
+			unspent[id] = append(unspent[id], vout)
+			accumulated += entry.Output.Value
+		}
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	return accumulated, unspent
+}
@@ -0,0 +1,184 @@
+package block
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+func TestDisconnectBlockDropsOutputsSpentInBlock(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	before := utxoSet(t, bc)
+
+	// t2 spends t1:0 within the same block
+	cb := b1.Transactions[0]
+	t1 := spendTx(w, cb, []int{0}, tx.NewTXOutput(cb.Vout[0].Value, w.Address()))
+	t2 := spendTx(w, t1, []int{0}, tx.NewTXOutput(t1.Vout[0].Value, testAddress))
+	b2 := newTestBlock(t, bc, b1, testAddress, t1, t2)
+	if err := bc.AcceptBlock(b2); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bc.findUTXO(t1.ID, 0); ok {
+		t.Fatal("t1:0 is unspent after b2 spent it")
+	}
+
+	err := bc.store.Update(func(txn StoreTx) error {
+		return disconnectBlock(txn, b2, 2)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bc.findUTXO(t1.ID, 0); ok {
+		t.Error("disconnecting b2 left t1:0 behind as a phantom output")
+	}
+	if after := utxoSet(t, bc); !reflect.DeepEqual(after, before) {
+		t.Errorf("UTXO set after disconnecting b2:\n%v\nwant the one before it:\n%v", after, before)
+	}
+}
+
+func TestUndoDataKeepsLargeEntries(t *testing.T) {
+	spent := []spentOutput{
+		{key: outpointKey(bytes.Repeat([]byte{1}, 32), 0), entry: utxoEntry{Height: 7, Output: tx.TXOutput{Value: 5, PubKeyHash: []byte{2}}}},
+		// wider than the 16-bit lengths of the legacy encoding
+		{key: outpointKey(bytes.Repeat([]byte{3}, 32), 9), entry: utxoEntry{Height: 8, Coinbase: true, Output: tx.TXOutput{Value: 6, PubKeyHash: bytes.Repeat([]byte{4}, 70000)}}},
+	}
+	got, err := deserializeUndo(serializeUndo(spent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, spent) {
+		t.Error("undo data changed in a round trip")
+	}
+
+	d := serializeUndo(spent)
+	for _, bad := range [][]byte{nil, d[:len(d)-1], append(d, 0)} {
+		if _, err := deserializeUndo(bad); err == nil {
+			t.Errorf("%d bytes of undo data: no error", len(bad))
+		}
+	}
+}
+
+func TestMigrateUndoData(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+	b2 := newTestBlock(t, bc, b1, testAddress, spendTx(w, cb, []int{0}, tx.NewTXOutput(1, testAddress)))
+	if err := bc.AcceptBlock(b2); err != nil {
+		t.Fatal(err)
+	}
+	b3 := newTestBlock(t, bc, b2, testAddress)
+	if err := bc.AcceptBlock(b3); err != nil {
+		t.Fatal(err)
+	}
+
+	// put b2's undo data back in the legacy layout, and give b3 a record
+	// whose entry length wrapped around
+	entry := utxoEntry{Height: 1, Coinbase: true, Output: cb.Vout[0]}.serialize()
+	legacy := append([]byte{36}, outpointKey(cb.ID, 0)...)
+	legacy = binary.BigEndian.AppendUint16(legacy, uint16(len(entry)))
+	legacy = append(legacy, entry...)
+	wrapped := append([]byte{36}, outpointKey(cb.ID, 0)...)
+	wrapped = binary.BigEndian.AppendUint16(wrapped, uint16(len(entry)+1<<16))
+	wrapped = append(wrapped, entry...)
+	err := bc.store.Update(func(txn StoreTx) error {
+		old, err := txn.CreateBucket(legacyUndoBucket)
+		if err != nil {
+			return err
+		}
+		if err := old.Put(b2.Hash, legacy); err != nil {
+			return err
+		}
+		if err := old.Put(b3.Hash, wrapped); err != nil {
+			return err
+		}
+		return txn.Bucket(undoBucket).Delete(b2.Hash)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = bc.store.Update(func(txn StoreTx) error {
+		return migrateUndoData(bc, txn, func(done, total int) {})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bc.store.View(func(txn StoreTx) error {
+		if txn.Bucket(legacyUndoBucket) != nil {
+			t.Error("legacy undo bucket is still there")
+		}
+		spent, err := deserializeUndo(txn.Bucket(undoBucket).Get(b2.Hash))
+		if err != nil {
+			return err
+		}
+		if len(spent) != 1 || !bytes.Equal(spent[0].key, outpointKey(cb.ID, 0)) || !bytes.Equal(spent[0].entry.serialize(), entry) {
+			t.Errorf("migrated undo data of b2 is %+v", spent)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSwitchChainStateRoundTrip(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+	genesis := tipBlock(t, bc)
+
+	// every block spends the output the block before paid to w
+	sets := []map[string]string{utxoSet(t, bc)}
+	blocks := []*Block{genesis}
+	prev := newTestBlock(t, bc, genesis, w.Address())
+	if err := bc.AcceptBlock(prev); err != nil {
+		t.Fatal(err)
+	}
+	sets, blocks = append(sets, utxoSet(t, bc)), append(blocks, prev)
+	paid := prev.Transactions[0]
+	for i := 0; i < 4; i++ {
+		spend := spendTx(w, paid, []int{0}, tx.NewTXOutput(paid.Vout[0].Value, w.Address()))
+		b := newTestBlock(t, bc, prev, testAddress, spend)
+		if err := bc.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		sets, blocks = append(sets, utxoSet(t, bc)), append(blocks, b)
+		prev, paid = b, spend
+	}
+
+	// disconnect down to every height and connect back up to the tip
+	tip := blocks[len(blocks)-1].Hash
+	for h, b := range blocks {
+		err := bc.store.Update(func(txn StoreTx) error {
+			return switchChainState(txn, tip, b.Hash)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := utxoSet(t, bc); !reflect.DeepEqual(got, sets[h]) {
+			t.Errorf("UTXO set disconnected down to height %d:\n%v\nwant:\n%v", h, got, sets[h])
+		}
+
+		err = bc.store.Update(func(txn StoreTx) error {
+			return switchChainState(txn, b.Hash, tip)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := utxoSet(t, bc); !reflect.DeepEqual(got, sets[len(sets)-1]) {
+			t.Errorf("UTXO set reconnected from height %d:\n%v\nwant:\n%v", h, got, sets[len(sets)-1])
+		}
+	}
+}
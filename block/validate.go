@@ -0,0 +1,356 @@
+package block
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+)
+
+// checkGenesis checks a genesis block that did not come from
+// NewGenesisBlock: it must carry the genesis payload and bits of params,
+// hash to its header and meet its proof of work
+func checkGenesis(g *Block, params *chaincfg.Params) error {
+	if len(g.PrevBlockHash) != 0 || g.Height != 0 || len(g.Transactions) != 0 {
+		return ruleError(ErrBadGenesis, "block %x is not a genesis block", g.Hash)
+	}
+	if string(g.Data) != genesisData(params) || g.Bits != params.GenesisBits {
+		return ruleError(ErrBadGenesis, "genesis block %x belongs to another network", g.Hash)
+	}
+	if header := g.Header(); !bytes.Equal(header.Hash(), g.Hash) {
+		return ruleError(ErrBadHash, "genesis header hashes to %x", header.Hash())
+	}
+	if !g.HasValidMerkleRoot() {
+		return ruleError(ErrBadMerkleRoot, "genesis merkle root does not match its contents")
+	}
+	if !proof.NewProofOfWork(g).Validate() {
+		return ruleError(ErrHighHash, "genesis hash is above the target for %d bits", g.Bits)
+	}
+	return nil
+}
+
+// checkBlockLimits enforces the transaction count, signature check and
+// serialized size limits of params. It runs before any other check so
+// oversized blocks are turned away cheaply.
+func checkBlockLimits(b *Block, params *chaincfg.Params) error {
+	if n := len(b.Transactions); n > params.MaxBlockTxs {
+		return ruleError(ErrTooManyTransactions,
+			"block has %d transactions, more than the MaxBlockTxs limit of %d", n, params.MaxBlockTxs)
+	}
+
+	sigOps := 0
+	for _, t := range b.Transactions {
+		// b.Serialize below cannot encode such inputs
+		if err := checkInputIndexes(t); err != nil {
+			return err
+		}
+		sigOps += t.SigOpCount()
+	}
+	if sigOps > params.MaxBlockSigOps {
+		return ruleError(ErrTooManySigOps,
+			"block needs %d signature checks, more than the MaxBlockSigOps limit of %d", sigOps, params.MaxBlockSigOps)
+	}
+
+	if size := len(b.Serialize()); size > params.MaxBlockSize {
+		return ruleError(ErrBlockTooBig,
+			"block is %d bytes, more than the MaxBlockSize limit of %d", size, params.MaxBlockSize)
+	}
+	return nil
+}
+
+// checkInputIndexes rejects a non-coinbase input whose output index is
+// negative or too big for the int32 of the transaction encoding
+func checkInputIndexes(t *tx.Transaction) error {
+	if t.IsCoinbase() {
+		return nil
+	}
+	for _, in := range t.Vin {
+		if in.Vout < 0 || in.Vout > math.MaxInt32 {
+			return ruleError(ErrMissingTxOut, "transaction spends output %x:%d, outside 0..%d", in.Txid, in.Vout, math.MaxInt32)
+		}
+	}
+	return nil
+}
+
+// txLookup finds the outputs a block spends: from earlier transactions in
+// the same block first, then from the chain the block builds on
+type txLookup struct {
+	bc      *Blockchain
+	from    []byte                     // hash of the block's parent
+	height  int64                      // height of the block being checked
+	inBlock map[string]*tx.Transaction // hex txid -> tx, filled as we go
+	spent   map[string]bool            // outpoints spent so far in the block
+	utxos   StoreBucket                // UTXO set as of the parent, if not the tip's
+}
+
+func (bc *Blockchain) newTxLookup(parent *Block) *txLookup {
+	return &txLookup{
+		bc:      bc,
+		from:    parent.Hash,
+		height:  bc.blockHeight(parent.Hash) + 1,
+		inBlock: make(map[string]*tx.Transaction),
+		spent:   make(map[string]bool),
+	}
+}
+
+// spentOutput returns output vout of transaction txid as a UTXO entry,
+// i.e. with the height and coinbase flag of its transaction. Blocks on the
+// tip read the UTXO set, and side branches the one switched to their
+// parent (see checkBranchTransactions), which also works once old blocks
+// are pruned, and Verify the one it replays from genesis. Only a lookup
+// whose parent stopped being the tip looks the transaction up along the
+// chain.
+func (l *txLookup) spentOutput(txid []byte, vout int) (utxoEntry, bool) {
+	if t, ok := l.inBlock[hex.EncodeToString(txid)]; ok {
+		if vout < 0 || vout >= len(t.Vout) {
+			return utxoEntry{}, false
+		}
+		return utxoEntry{Height: l.height, Coinbase: t.IsCoinbase(), Output: t.Vout[vout]}, true
+	}
+	if l.utxos != nil {
+		if d := l.utxos.Get(outpointKey(txid, vout)); d != nil {
+			return deserializeUTXOEntry(d), true
+		}
+		return utxoEntry{}, false
+	}
+	if bytes.Equal(l.from, l.bc.Tip()) {
+		return l.bc.findUTXO(txid, vout)
+	}
+
+	prev, height := l.bc.findTransactionFrom(l.from, txid)
+	if prev == nil || vout < 0 || vout >= len(prev.Vout) {
+		return utxoEntry{}, false
+	}
+	return utxoEntry{Height: height, Coinbase: prev.IsCoinbase(), Output: prev.Vout[vout]}, true
+}
+
+// errDiscard rolls back a store transaction whose changes were only needed
+// for the checks made inside it
+var errDiscard = errors.New("store transaction discarded")
+
+// checkBranchTransactions runs checkTransactions for b on a side branch.
+// Inside a store transaction that is always rolled back, the UTXO set is
+// switched from the tip to parent with the undo data, exactly as a reorg
+// would, so b is checked against the outputs unspent on its own branch
+// whether or not old block bodies are pruned. Branches forking below the
+// prune height fail with ErrReorgTooDeep, as the node could never switch
+// to them.
+func (bc *Blockchain) checkBranchTransactions(b *Block, parent *Block, verifySigs bool) error {
+	// the lookup must not open store transactions of its own inside the
+	// update, so it is set up first
+	lookup := bc.newTxLookup(parent)
+
+	var checkErr error
+	err := bc.store.Update(func(txn StoreTx) error {
+		if err := switchChainState(txn, txn.Tip(), parent.Hash); err != nil {
+			return err
+		}
+		lookup.utxos = txn.Bucket(utxoBucket)
+		checkErr = bc.checkTransactions(b, lookup, verifySigs)
+		return errDiscard
+	})
+	if err != errDiscard {
+		return err
+	}
+	return checkErr
+}
+
+// findTransactionFrom finds a transaction on the chain ending at hash and
+// returns it with the height of its block. The transaction index answers
+// for the active chain; without it the chain is scanned.
+func (bc *Blockchain) findTransactionFrom(hash []byte, id []byte) (*tx.Transaction, int64) {
+	if t, height, ok := bc.findIndexedTransaction(hash, id); ok {
+		return t, height
+	}
+	b, pos := bc.scanTransaction(hash, id)
+	if b == nil {
+		return nil, 0
+	}
+	return b.Transactions[pos], bc.blockHeight(b.Hash)
+}
+
+// inputValue sums the outputs spent by a non-coinbase transaction and
+// marks them spent, so no later input of the block can spend them again.
+// Coinbase outputs can only be spent once they are CoinbaseMaturity blocks
+// deep.
+func (l *txLookup) inputValue(t *tx.Transaction) (int64, error) {
+	var total int64
+	for _, in := range t.Vin {
+		key := string(outpointKey(in.Txid, in.Vout))
+		if l.spent[key] {
+			return 0, ruleError(ErrMissingTxOut,
+				"transaction %x spends output %x:%d, which the block already spent", t.ID, in.Txid, in.Vout)
+		}
+		prev, ok := l.spentOutput(in.Txid, in.Vout)
+		if !ok {
+			return 0, ruleError(ErrMissingTxOut,
+				"transaction %x spends unknown output %x:%d", t.ID, in.Txid, in.Vout)
+		}
+		l.spent[key] = true
+		if prev.Coinbase {
+			if depth := l.height - prev.Height; depth < l.bc.params.CoinbaseMaturity {
+				return 0, ruleError(ErrImmatureSpend,
+					"transaction %x spends coinbase %x which is only %d blocks deep, %d required",
+					t.ID, in.Txid, depth, l.bc.params.CoinbaseMaturity)
+			}
+		}
+		if total, ok = addMoney(total, int64(prev.Output.Value), l.bc.params.MaxSupply); !ok {
+			return 0, ruleError(ErrBadTxOutValue,
+				"inputs of transaction %x add up to more than %d", t.ID, l.bc.params.MaxSupply)
+		}
+	}
+	return total, nil
+}
+
+// addMoney adds v to total, which has to be within 0..maxMoney already. It
+// reports false if v is outside that range or the sum would go above it,
+// so values can never overflow int64.
+func addMoney(total, v, maxMoney int64) (int64, bool) {
+	if v < 0 || v > maxMoney || total > maxMoney-v {
+		return total, false
+	}
+	return total + v, true
+}
+
+// outputValue sums the outputs of t. Every output and the sum have to be
+// within 0..maxMoney.
+func outputValue(t *tx.Transaction, maxMoney int64) (int64, error) {
+	var total int64
+	for i, out := range t.Vout {
+		if out.Value < 0 || int64(out.Value) > maxMoney {
+			return 0, ruleError(ErrBadTxOutValue,
+				"output %d of transaction %x is %d, outside 0..%d", i, t.ID, out.Value, maxMoney)
+		}
+		var ok bool
+		if total, ok = addMoney(total, int64(out.Value), maxMoney); !ok {
+			return 0, ruleError(ErrBadTxOutValue,
+				"outputs of transaction %x add up to more than %d", t.ID, maxMoney)
+		}
+	}
+	return total, nil
+}
+
+// fees returns what the transactions leave for the miner, in order
+func (l *txLookup) fees(txs []*tx.Transaction) (int64, error) {
+	var total int64
+	for _, t := range txs {
+		in, err := l.inputValue(t)
+		if err != nil {
+			return 0, err
+		}
+		out, err := outputValue(t, l.bc.params.MaxSupply)
+		if err != nil {
+			return 0, err
+		}
+		if out > in {
+			return 0, ruleError(ErrSpendTooHigh,
+				"transaction %x spends %d but only has %d in inputs", t.ID, out, in)
+		}
+		var ok bool
+		if total, ok = addMoney(total, in-out, l.bc.params.MaxSupply); !ok {
+			return 0, ruleError(ErrBadTxOutValue, "fees add up to more than %d", l.bc.params.MaxSupply)
+		}
+		l.inBlock[hex.EncodeToString(t.ID)] = t
+	}
+	return total, nil
+}
+
+// checkTransactions checks the transactions of b at height: every ID has to
+// match its transaction, every transaction but the coinbase needs inputs
+// with output indexes in 0..MaxInt32, only the first one may be a coinbase and it has to commit to height, no
+// transaction may spend more than its inputs or an immature coinbase, and
+// the coinbase may pay at most the block subsidy plus fees. With verifySigs
+// set every input also has to be signed by the owner of the output it
+// spends.
+func (bc *Blockchain) checkTransactions(b *Block, lookup *txLookup, verifySigs bool) error {
+	height := lookup.height
+
+	var fees, coinbaseValue int64
+	for i, t := range b.Transactions {
+		if err := checkInputIndexes(t); err != nil {
+			return err
+		}
+		if !bytes.Equal(t.ID, t.Hash()) {
+			return ruleError(ErrBadTxID, "transaction %d has ID %x but hashes to %x", i, t.ID, t.Hash())
+		}
+		if !t.IsCoinbase() && len(t.Vin) == 0 {
+			return ruleError(ErrNoTxInputs, "transaction %x has no inputs", t.ID)
+		}
+
+		out, err := outputValue(t, bc.params.MaxSupply)
+		if err != nil {
+			return err
+		}
+
+		if t.IsCoinbase() {
+			if i != 0 {
+				return ruleError(ErrMultipleCoinbases, "transaction %d of block %x is a second coinbase", i, b.Hash)
+			}
+			if committed, err := t.CoinbaseHeight(); err != nil || committed != height {
+				return ruleError(ErrBadCoinbaseHeight,
+					"coinbase of block %x does not commit to height %d", b.Hash, height)
+			}
+			coinbaseValue = out
+		} else {
+			in, err := lookup.inputValue(t)
+			if err != nil {
+				return err
+			}
+			if out > in {
+				return ruleError(ErrSpendTooHigh,
+					"transaction %x spends %d but only has %d in inputs", t.ID, out, in)
+			}
+			if verifySigs {
+				if err := lookup.verifySignatures(t); err != nil {
+					return err
+				}
+			}
+			var ok bool
+			if fees, ok = addMoney(fees, in-out, bc.params.MaxSupply); !ok {
+				return ruleError(ErrBadTxOutValue, "fees of block %x add up to more than %d", b.Hash, bc.params.MaxSupply)
+			}
+		}
+
+		lookup.inBlock[hex.EncodeToString(t.ID)] = t
+	}
+
+	if limit := bc.params.BlockSubsidy(height) + fees; coinbaseValue > limit {
+		return ruleError(ErrBadCoinbaseValue,
+			"coinbase pays %d, more than subsidy plus fees of %d", coinbaseValue, limit)
+	}
+	return nil
+}
+
+// verifySignatures checks that every input of t is signed by the owner of
+// the output it spends
+func (l *txLookup) verifySignatures(t *tx.Transaction) error {
+	if t.IsCoinbase() {
+		return nil
+	}
+
+	prevOuts := make(map[string]tx.TXOutput)
+	for _, in := range t.Vin {
+		prev, ok := l.spentOutput(in.Txid, in.Vout)
+		if !ok {
+			return ruleError(ErrMissingTxOut,
+				"transaction %x spends unknown output %x:%d", t.ID, in.Txid, in.Vout)
+		}
+		out := prev.Output
+
+		if !bytes.Equal(wallet.PubKeyHash(in.PubKey), out.PubKeyHash) {
+			return ruleError(ErrBadTxSignature,
+				"transaction %x spends %x:%d with a key that does not own it", t.ID, in.Txid, in.Vout)
+		}
+		prevOuts[hex.EncodeToString(append(in.Txid, byte(in.Vout)))] = out
+	}
+
+	if !t.Verify(prevOuts) {
+		return ruleError(ErrBadTxSignature, "transaction %x has an invalid signature", t.ID)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package block
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+func TestAcceptBlockRejectsDoubleSpends(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+	value := cb.Vout[0].Value
+	pay := func(v int) *tx.Transaction {
+		return spendTx(w, cb, []int{0}, tx.NewTXOutput(v, testAddress))
+	}
+
+	tests := []struct {
+		name string
+		txs  []*tx.Transaction
+	}{
+		{"across transactions", []*tx.Transaction{pay(value), pay(value - 1)}},
+		{"within a transaction", []*tx.Transaction{spendTx(w, cb, []int{0, 0}, tx.NewTXOutput(2*value, testAddress))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" on the tip", func(t *testing.T) {
+			wantRuleError(t, bc.AcceptBlock(newTestBlock(t, bc, b1, testAddress, tt.txs...)), ErrMissingTxOut)
+		})
+	}
+
+	// the same blocks on a side branch forking at b1
+	acceptTestBlocks(t, bc, b1, 1)
+	for _, tt := range tests {
+		t.Run(tt.name+" on a side branch", func(t *testing.T) {
+			wantRuleError(t, bc.AcceptBlock(newTestBlock(t, bc, b1, testAddress, tt.txs...)), ErrMissingTxOut)
+		})
+	}
+
+	// a single spend is fine on both
+	if err := bc.AcceptBlock(newTestBlock(t, bc, b1, testAddress, pay(value))); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckBlockVerifiesHeaderFirst(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+
+	// spends an output that does not exist and has the wrong bits
+	unknown := tx.NewCoinbaseTX(w.Address(), 50, 99, 0)
+	b := newTestBlock(t, bc, tipBlock(t, bc), testAddress, spendTx(w, unknown, []int{0}, tx.NewTXOutput(50, testAddress)))
+	b.Bits++
+	wantRuleError(t, bc.AcceptBlock(b), ErrUnexpectedDifficulty)
+}
+
+func TestRejectsOutputIndexesOutsideInt32(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+
+	tooBig := math.MaxInt32
+	tooBig++ // a variable, so this builds where int is 32 bits
+	for _, vout := range []int{-1, -2, tooBig} {
+		// sealed while the index still encodes, then changed
+		b := newTestBlock(t, bc, b1, testAddress, spendTx(w, cb, []int{0}, tx.NewTXOutput(1, testAddress)))
+		b.Transactions[1].Vin[0].Vout = vout
+		wantRuleError(t, bc.checkTransactions(b, bc.newTxLookup(b1), true), ErrMissingTxOut)
+		wantRuleError(t, bc.AcceptBlock(b), ErrMissingTxOut)
+	}
+}
@@ -0,0 +1,211 @@
+package block
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+)
+
+// VerifyLevel selects how thoroughly Verify checks each block. Every level
+// includes the checks of the levels below it.
+type VerifyLevel int
+
+const (
+	// VerifyHeaders checks the previous-block links, recomputes every block
+	// hash and checks the seal: the difficulty bits and the proof of work,
+	// or the PoA signer and signature
+	VerifyHeaders VerifyLevel = iota
+
+	// VerifyBlocks adds timestamp, merkle root and block limit checks
+	VerifyBlocks
+
+	// VerifyTransactions adds the transaction rules and checks every input
+	// signature against the output it spends. The UTXO set is rebuilt from
+	// genesis along the way, so an output spent twice is caught even when
+	// the two spends are blocks apart.
+	VerifyTransactions
+)
+
+// verifyProgressInterval is how many blocks pass between progress logs
+const verifyProgressInterval = 1000
+
+// VerifyError identifies the first block that failed verification
+type VerifyError struct {
+	Height int64
+	Hash   []byte
+	Err    error
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("block %d (%x): %v", e.Height, e.Hash, e.Err)
+}
+
+// VerifyReport summarises a verification run
+type VerifyReport struct {
+	Level        VerifyLevel
+	Blocks       int64 // blocks checked, genesis included
+	Transactions int64
+	Signatures   int64
+	Elapsed      time.Duration
+}
+
+func (r VerifyReport) String() string {
+	return fmt.Sprintf("verified %d blocks, %d transactions and %d signatures at level %d in %s",
+		r.Blocks, r.Transactions, r.Signatures, r.Level, r.Elapsed.Round(time.Millisecond))
+}
+
+// Verify walks the active chain from genesis to tip and checks it at level.
+// It stops at the first bad block and returns a *VerifyError for it; the
+// report covers the blocks checked until then. Pruned blocks only get
+// their headers checked, and a pruned chain cannot be checked at
+// VerifyTransactions.
+func (bc *Blockchain) Verify(level VerifyLevel) (VerifyReport, error) {
+	start := time.Now()
+	report := VerifyReport{Level: level}
+
+	// transactions spend outputs of pruned blocks, which are gone
+	prunedTo := bc.PruneHeight()
+	if prunedTo > 0 && level >= VerifyTransactions {
+		return report, fmt.Errorf("transaction checks need every block, but blocks up to height %d are pruned", prunedTo)
+	}
+
+	// the outputs spent so far are replayed into a scratch UTXO set
+	var utxos *MemStore
+	if level >= VerifyTransactions {
+		utxos = NewMemStore()
+		err := utxos.Update(func(txn StoreTx) error {
+			for _, name := range chainStateBuckets {
+				if _, err := txn.CreateBucket(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	// walk the height index up from genesis, holding only the parent
+	tip, best := bc.Tip(), bc.BestHeight()
+	var parent *Block
+	for h := int64(0); h <= best; h++ {
+		b, err := bc.activeBlock(h)
+		if err != nil {
+			report.Elapsed = time.Since(start)
+			return report, err
+		}
+		if h == best && !bytes.Equal(b.Hash, tip) {
+			report.Elapsed = time.Since(start)
+			return report, &VerifyError{Height: h, Hash: b.Hash, Err: fmt.Errorf("height index ends at another block than the tip %x", tip)}
+		}
+
+		blockLevel := level
+		if h > 0 && h <= prunedTo {
+			blockLevel = VerifyHeaders
+		}
+		if err := bc.verifyBlock(b, parent, h, blockLevel, utxos, &report); err != nil {
+			report.Elapsed = time.Since(start)
+			return report, &VerifyError{Height: h, Hash: b.Hash, Err: err}
+		}
+		report.Blocks++
+		parent = b
+
+		if report.Blocks%verifyProgressInterval == 0 {
+			log.Printf("🔎 Verified %d/%d blocks", h, best)
+		}
+	}
+
+	report.Elapsed = time.Since(start)
+	return report, nil
+}
+
+// activeBlock loads the block at height on the active chain for Verify.
+// Heights missing from the index, missing blocks and blocks stored under
+// the wrong key are reported as a *VerifyError at that height.
+func (bc *Blockchain) activeBlock(height int64) (*Block, error) {
+	hash := bc.activeHash(height)
+	if hash == nil {
+		return nil, &VerifyError{Height: height, Err: fmt.Errorf("height index has no block")}
+	}
+	b := bc.getBlock(hash)
+	if b == nil {
+		return nil, &VerifyError{Height: height, Hash: hash, Err: fmt.Errorf("block is missing from the database")}
+	}
+	if !bytes.Equal(b.Hash, hash) {
+		return nil, &VerifyError{Height: height, Hash: hash, Err: fmt.Errorf("stored block has hash %x", b.Hash)}
+	}
+	return b, nil
+}
+
+// verifyBlock checks one block of the active chain; parent is nil for
+// genesis. At VerifyTransactions the block is checked against and then
+// connected to utxos, the UTXO set as of its parent.
+func (bc *Blockchain) verifyBlock(b, parent *Block, height int64, level VerifyLevel, utxos *MemStore, report *VerifyReport) error {
+	if parent != nil && !bytes.Equal(b.PrevBlockHash, parent.Hash) {
+		return fmt.Errorf("previous block hash %x does not link to %x", b.PrevBlockHash, parent.Hash)
+	}
+	if entry, ok := bc.indexEntry(b.Hash); ok && entry.Height != height {
+		return fmt.Errorf("block index records height %d", entry.Height)
+	}
+	if !b.heightMatches(height) {
+		return ruleError(ErrBadHeight, "block claims height %d", b.Height)
+	}
+
+	if header := b.Header(); !bytes.Equal(header.Hash(), b.Hash) {
+		return ruleError(ErrBadHash, "header hashes to %x", header.Hash())
+	}
+	if parent != nil {
+		if err := bc.engine.VerifyHeader(bc, b); err != nil {
+			return ruleError(sealErrorCode(err), "%v", err)
+		}
+	} else if bc.params.Consensus == chaincfg.ProofOfWork && !proof.NewProofOfWork(b).Validate() {
+		return ruleError(ErrHighHash, "hash is above the target for %d bits", b.Bits)
+	}
+
+	if level < VerifyBlocks {
+		return nil
+	}
+	if !b.HasValidMerkleRoot() {
+		return ruleError(ErrBadMerkleRoot, "merkle root does not match the block contents")
+	}
+	if err := checkBlockLimits(b, bc.params); err != nil {
+		return err
+	}
+	if parent != nil {
+		if err := bc.checkTimestamp(b, parent); err != nil {
+			return err
+		}
+	}
+
+	if level < VerifyTransactions {
+		return nil
+	}
+	err := utxos.Update(func(txn StoreTx) error {
+		if parent != nil {
+			// unlike block acceptance, assume-valid does not apply here
+			lookup := bc.newTxLookup(parent)
+			lookup.utxos = txn.Bucket(utxoBucket)
+			if err := bc.checkTransactions(b, lookup, true); err != nil {
+				return err
+			}
+		}
+		if err := connectBlock(txn, b, height); err != nil {
+			return err
+		}
+		// nothing is disconnected, so the undo data is not needed
+		return txn.Bucket(undoBucket).Delete(b.Hash)
+	})
+	if err != nil {
+		return err
+	}
+	report.Transactions += int64(len(b.Transactions))
+	for _, t := range b.Transactions {
+		report.Signatures += int64(t.SigOpCount())
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+)
+
+// appendUnchecked makes b the new tip without any of the checks of
+// AcceptBlock, the way a corrupted or tampered database could hold it
+func appendUnchecked(t *testing.T, bc *Blockchain, b *Block) {
+	t.Helper()
+	err := bc.store.Update(func(txn StoreTx) error {
+		parent, _ := readIndexEntry(txn, b.PrevBlockHash)
+		entry := blockIndexEntry{
+			Height:    parent.Height + 1,
+			ChainWork: new(big.Int).Add(parent.ChainWork, bc.engine.Work(b)),
+		}
+		if err := txn.PutBlock(b); err != nil {
+			return err
+		}
+		if err := txn.Bucket(blockIndexBucket).Put(b.Hash, entry.serialize()); err != nil {
+			return err
+		}
+		if err := txn.Bucket(heightIndexBucket).Put(heightKey(entry.Height), b.Hash); err != nil {
+			return err
+		}
+		return txn.SetTip(b.Hash)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc.mu.Lock()
+	bc.tip = b.Hash
+	bc.mu.Unlock()
+}
+
+// wantVerifyError fails the test unless Verify at level stops at height
+// with a RuleError with code
+func wantVerifyError(t *testing.T, bc *Blockchain, level VerifyLevel, height int64, code ErrorCode) {
+	t.Helper()
+	_, err := bc.Verify(level)
+	var verr *VerifyError
+	if !errors.As(err, &verr) || verr.Height != height {
+		t.Fatalf("level %d: got %v, want an error at height %d", level, err, height)
+	}
+	wantRuleError(t, verr.Err, code)
+}
+
+func TestVerify(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+	b2 := newTestBlock(t, bc, b1, testAddress, spendTx(w, cb, []int{0}, tx.NewTXOutput(cb.Vout[0].Value, testAddress)))
+	if err := bc.AcceptBlock(b2); err != nil {
+		t.Fatal(err)
+	}
+	acceptTestBlocks(t, bc, b2, 3)
+
+	for level := VerifyHeaders; level <= VerifyTransactions; level++ {
+		report, err := bc.Verify(level)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if report.Blocks != 6 {
+			t.Errorf("level %d: verified %d blocks, want 6", level, report.Blocks)
+		}
+	}
+	if report, _ := bc.Verify(VerifyTransactions); report.Transactions != 6 || report.Signatures != 1 {
+		t.Errorf("verified %d transactions and %d signatures, want 6 and 1", report.Transactions, report.Signatures)
+	}
+}
+
+func TestVerifyCatchesSpendsBlocksApart(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	w := newTestWallet(t)
+	b1 := newTestBlock(t, bc, tipBlock(t, bc), w.Address())
+	if err := bc.AcceptBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	cb := b1.Transactions[0]
+	b2 := newTestBlock(t, bc, b1, testAddress, spendTx(w, cb, []int{0}, tx.NewTXOutput(cb.Vout[0].Value, testAddress)))
+	if err := bc.AcceptBlock(b2); err != nil {
+		t.Fatal(err)
+	}
+	b3 := acceptTestBlocks(t, bc, b2, 1)
+
+	// b4 spends the coinbase of b1 a second time
+	appendUnchecked(t, bc, newTestBlock(t, bc, b3, testAddress, spendTx(w, cb, []int{0}, tx.NewTXOutput(1, testAddress))))
+
+	if _, err := bc.Verify(VerifyBlocks); err != nil {
+		t.Fatal(err)
+	}
+	wantVerifyError(t, bc, VerifyTransactions, 4, ErrMissingTxOut)
+}
+
+func TestVerifyChecksDifficulty(t *testing.T) {
+	bc := newTestChain(t, testParams())
+	tip := acceptTestBlocks(t, bc, tipBlock(t, bc), 2)
+
+	// a valid proof of work, but for less than the required bits
+	b := newTestBlock(t, bc, tip, testAddress)
+	b.Bits = 0
+	if err := bc.engine.Seal(context.Background(), bc, b); err != nil {
+		t.Fatal(err)
+	}
+	appendUnchecked(t, bc, b)
+
+	wantVerifyError(t, bc, VerifyHeaders, 3, ErrUnexpectedDifficulty)
+}
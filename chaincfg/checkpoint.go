@@ -0,0 +1,106 @@
+package chaincfg
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCheckpoint reads a checkpoint written as "height:hash"
+func ParseCheckpoint(s string) (Checkpoint, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q is not height:hash", s)
+	}
+
+	height, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || height < 0 {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q has an invalid height", s)
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || len(parts[1]) != 64 {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q has an invalid hash", s)
+	}
+	return Checkpoint{Height: height, Hash: strings.ToLower(parts[1])}, nil
+}
+
+// SetCheckpoints replaces the checkpoints with the "height:hash" entries of
+// specs, e.g. from the command line
+func (p *Params) SetCheckpoints(specs []string) error {
+	checkpoints := make([]Checkpoint, 0, len(specs))
+	for _, spec := range specs {
+		cp, err := ParseCheckpoint(spec)
+		if err != nil {
+			return err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].Height < checkpoints[j].Height })
+	p.Checkpoints = checkpoints
+	return nil
+}
+
+// SetAssumeValid replaces the assume-valid block with the "height:hash"
+// spec; "none" checks every signature
+func (p *Params) SetAssumeValid(spec string) error {
+	if spec == "none" {
+		p.AssumeValid = nil
+		return nil
+	}
+	cp, err := ParseCheckpoint(spec)
+	if err != nil {
+		return err
+	}
+	p.AssumeValid = &cp
+	return nil
+}
+
+// SetAssumeUTXO replaces the trusted UTXO set snapshots with the
+// "height:hash" entries of specs
+func (p *Params) SetAssumeUTXO(specs []string) error {
+	snapshots := make([]Checkpoint, 0, len(specs))
+	for _, spec := range specs {
+		cp, err := ParseCheckpoint(spec)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, cp)
+	}
+	p.AssumeUTXO = snapshots
+	return nil
+}
+
+// TrustedTxOutSet returns the hash a UTXO set snapshot based at height
+// must have, if one is trusted
+func (p *Params) TrustedTxOutSet(height int64) (string, bool) {
+	for _, cp := range p.AssumeUTXO {
+		if cp.Height == height {
+			return cp.Hash, true
+		}
+	}
+	return "", false
+}
+
+// Checkpoint returns the checkpoint at height, if there is one
+func (p *Params) Checkpoint(height int64) (Checkpoint, bool) {
+	for _, cp := range p.Checkpoints {
+		if cp.Height == height {
+			return cp, true
+		}
+	}
+	if p.AssumeValid != nil && p.AssumeValid.Height == height {
+		return *p.AssumeValid, true
+	}
+	return Checkpoint{}, false
+}
+
+// LastCheckpoint returns the highest checkpoint at or below height
+func (p *Params) LastCheckpoint(height int64) (Checkpoint, bool) {
+	for i := len(p.Checkpoints) - 1; i >= 0; i-- {
+		if p.Checkpoints[i].Height <= height {
+			return p.Checkpoints[i], true
+		}
+	}
+	return Checkpoint{}, false
+}
@@ -0,0 +1,147 @@
+package chaincfg
+
+// ConsensusType selects the engine that seals and verifies blocks
+type ConsensusType int
+
+const (
+	// ProofOfWork seals blocks by searching for a nonce below the target
+	ProofOfWork ConsensusType = iota
+
+	// ProofOfAuthority seals blocks with the key of an authorised signer
+	ProofOfAuthority
+)
+
+func (c ConsensusType) String() string {
+	if c == ProofOfAuthority {
+		return "poa"
+	}
+	return "pow"
+}
+
+// Checkpoint pins the block at Height to the hex encoded Hash
+type Checkpoint struct {
+	Height int64
+	Hash   string
+}
+
+// Params holds the consensus rules a chain is started with
+type Params struct {
+	Name string
+
+	// Consensus is fixed for the lifetime of a chain, from genesis on
+	Consensus ConsensusType
+
+	// GenesisBits is the difficulty of the genesis block
+	GenesisBits uint32
+
+	// MinBits and MaxBits bound every retarget
+	MinBits uint32
+	MaxBits uint32
+
+	// TargetSpacing is the desired time between blocks, in seconds
+	TargetSpacing int64
+
+	// RetargetInterval is the number of blocks between difficulty changes
+	RetargetInterval int64
+
+	// InitialSubsidy is the coinbase reward of the first block
+	InitialSubsidy int64
+
+	// SubsidyHalvingInterval is the number of blocks between halvings
+	SubsidyHalvingInterval int64
+
+	// MaxSupply caps the total amount ever paid out by coinbases
+	MaxSupply int64
+
+	// CoinbaseMaturity is how many blocks deep a coinbase has to be before
+	// its outputs can be spent
+	CoinbaseMaturity int64
+
+	// MaxBlockSize is the largest serialized block, in bytes
+	MaxBlockSize int
+
+	// MaxBlockTxs is the most transactions a block may hold
+	MaxBlockTxs int
+
+	// MaxBlockSigOps is the most signature checks a block may require
+	MaxBlockSigOps int
+
+	// Checkpoints are known-good blocks, in ascending height order. A block
+	// at a checkpoint height must match it, and no fork may branch off below
+	// the highest checkpoint the chain has reached.
+	Checkpoints []Checkpoint
+
+	// AssumeValid is a block whose ancestors are trusted to carry valid
+	// signatures. Only blocks a known header chain places below it skip
+	// signature checks; they are still checked for proof of work, linkage
+	// and the other rules. The block at its height must match it. Nil
+	// checks every signature.
+	AssumeValid *Checkpoint
+
+	// AssumeUTXO lists the UTXO set snapshots a chain may be started from,
+	// as the height of their base block and their hex snapshot hash, which
+	// also commits to the base block. Snapshots not listed are refused.
+	AssumeUTXO []Checkpoint
+
+	// MaxTimeDrift is how many seconds a block may be ahead of
+	// network-adjusted time
+	MaxTimeDrift int64
+
+	// Signers are the addresses allowed to seal blocks at genesis (PoA only)
+	Signers []string
+
+	// Period is the minimum number of seconds between PoA blocks
+	Period int64
+
+	// Epoch is the number of PoA blocks after which pending votes are dropped
+	Epoch int64
+}
+
+// MainNetParams are the rules used by the default chain
+var MainNetParams = Params{
+	Name:             "mainnet",
+	GenesisBits:      16,
+	MinBits:          8,
+	MaxBits:          48,
+	TargetSpacing:    10,
+	RetargetInterval: 20,
+
+	InitialSubsidy:         50,
+	SubsidyHalvingInterval: 210000,
+	MaxSupply:              21000000,
+	CoinbaseMaturity:       100,
+
+	MaxBlockSize:   1000000,
+	MaxBlockTxs:    4000,
+	MaxBlockSigOps: 20000,
+
+	MaxTimeDrift: 2 * 60 * 60,
+}
+
+// PoANetParams describe a proof-of-authority test network. The genesis
+// signer set has to be filled in by whoever starts the network.
+var PoANetParams = Params{
+	Name:      "poa",
+	Consensus: ProofOfAuthority,
+
+	InitialSubsidy:         50,
+	SubsidyHalvingInterval: 210000,
+	MaxSupply:              21000000,
+	CoinbaseMaturity:       100,
+
+	MaxBlockSize:   1000000,
+	MaxBlockTxs:    4000,
+	MaxBlockSigOps: 20000,
+
+	MaxTimeDrift: 15,
+	Period:       5,
+	Epoch:        100,
+}
+
+// ActiveNetParams are the parameters the node is currently running with
+var ActiveNetParams = &MainNetParams
+
+// TargetTimespan is the expected time covered by one retarget window
+func (p *Params) TargetTimespan() int64 {
+	return p.TargetSpacing * (p.RetargetInterval - 1)
+}
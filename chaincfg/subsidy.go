@@ -0,0 +1,54 @@
+package chaincfg
+
+// rawSubsidy is the halving schedule before the supply cap is applied.
+// Genesis (height 0) pays nothing.
+func (p *Params) rawSubsidy(height int64) int64 {
+	if height <= 0 {
+		return 0
+	}
+	halvings := (height - 1) / p.SubsidyHalvingInterval
+	if halvings >= 63 {
+		return 0
+	}
+	return p.InitialSubsidy >> uint(halvings)
+}
+
+// rawSupply sums rawSubsidy over heights 1..height, one halving era at a time
+func (p *Params) rawSupply(height int64) int64 {
+	var total int64
+	for start := int64(1); start <= height; start += p.SubsidyHalvingInterval {
+		subsidy := p.rawSubsidy(start)
+		if subsidy == 0 {
+			break
+		}
+		end := start + p.SubsidyHalvingInterval - 1
+		if end > height {
+			end = height
+		}
+		total += subsidy * (end - start + 1)
+	}
+	return total
+}
+
+// BlockSubsidy returns the new coins a block at height may create. The
+// reward halves every SubsidyHalvingInterval blocks, and the block that
+// reaches MaxSupply only gets what is left under the cap.
+func (p *Params) BlockSubsidy(height int64) int64 {
+	subsidy := p.rawSubsidy(height)
+	if left := p.MaxSupply - p.rawSupply(height-1); subsidy > left {
+		subsidy = left
+	}
+	if subsidy < 0 {
+		return 0
+	}
+	return subsidy
+}
+
+// TotalSupply returns the coins scheduled to exist after the block at height
+func (p *Params) TotalSupply(height int64) int64 {
+	supply := p.rawSupply(height)
+	if supply > p.MaxSupply {
+		return p.MaxSupply
+	}
+	return supply
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/Shubham0699/go-mini-blockchain/block"
 
 	"github.com/spf13/cobra"
 )
@@ -13,9 +12,11 @@ var addBlockCmd = &cobra.Command{
 	Use:   "addblock",
 	Short: "Add a block to the blockchain",
 	Run: func(cmd *cobra.Command, args []string) {
-		bc := block.GetBlockchain()
+		bc := openBlockchain()
+		bc.SetMiningWorkers(minerWorkers)
 		bc.AddBlock(data)
 		fmt.Println("✅ Block added with data:", data)
+		fmt.Println("⛏️", bc.LastMiningStats())
 	},
 }
 
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historySkip  int
+	historyCount int
+)
+
+var addressHistoryCmd = &cobra.Command{
+	Use:   "addresshistory <address>",
+	Short: "List the transactions that funded or spent from an address",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pubKeyHash, err := hex.DecodeString(args[0])
+		if err != nil {
+			fmt.Println("❌ Invalid address:", err)
+			return
+		}
+
+		bc := openBlockchain()
+		defer bc.Close()
+
+		history, total, err := bc.AddressHistory(pubKeyHash, historySkip, historyCount)
+		if err != nil {
+			fmt.Println("❌", err, "(run with --addrindex to build it)")
+			return
+		}
+
+		for _, h := range history {
+			kind := ""
+			if h.Funding {
+				kind += " funding"
+			}
+			if h.Spending {
+				kind += " spending"
+			}
+			fmt.Printf("%x  height %d, position %d,%s\n", h.TxID, h.BlockHeight, h.Position, kind)
+		}
+		fmt.Printf("Showing %d of %d transactions\n", len(history), total)
+	},
+}
+
+func init() {
+	addressHistoryCmd.Flags().IntVar(&historySkip, "skip", 0, "number of transactions to skip, oldest first")
+	addressHistoryCmd.Flags().IntVar(&historyCount, "count", 20, "number of transactions to show")
+	rootCmd.AddCommand(addressHistoryCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+	"github.com/spf13/cobra"
+)
+
+var createWalletCmd = &cobra.Command{
+	Use:   "createwallet",
+	Short: "Generate a new key pair and print its address",
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wallet.NewWallet()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Address:    ", w.Address())
+		fmt.Println("Private key:", w.PrivateKeyHex())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(createWalletCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Shubham0699/go-mini-blockchain/block"
+	"github.com/spf13/cobra"
+)
+
+var (
+	getBlockHeight int64
+	getBlockHash   string
+)
+
+var getBlockCmd = &cobra.Command{
+	Use:   "getblock",
+	Short: "Print a block by height or hash as JSON",
+	Run: func(cmd *cobra.Command, args []string) {
+		byHeight := cmd.Flags().Changed("height")
+		if byHeight == (getBlockHash != "") {
+			fmt.Println("❌ Pass exactly one of --height or --hash")
+			os.Exit(1)
+		}
+
+		bc := openBlockchain()
+		defer bc.Close()
+
+		var blk *block.Block
+		var err error
+		if byHeight {
+			blk, err = bc.GetBlockByHeight(getBlockHeight)
+		} else {
+			hash, decodeErr := hex.DecodeString(getBlockHash)
+			if decodeErr != nil {
+				fmt.Println("❌ Invalid block hash:", decodeErr)
+				return
+			}
+			blk, err = bc.GetBlockByHash(hash)
+		}
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+
+		out, _ := json.MarshalIndent(blk, "", "  ")
+		fmt.Println(string(out))
+	},
+}
+
+var bestHeightCmd = &cobra.Command{
+	Use:   "bestheight",
+	Short: "Print the height of the chain tip",
+	Run: func(cmd *cobra.Command, args []string) {
+		bc := openBlockchain()
+		defer bc.Close()
+
+		fmt.Println(bc.BestHeight())
+	},
+}
+
+func init() {
+	getBlockCmd.Flags().Int64Var(&getBlockHeight, "height", 0, "height of the block on the active chain")
+	getBlockCmd.Flags().StringVar(&getBlockHash, "hash", "", "hex hash of the block")
+	rootCmd.AddCommand(getBlockCmd)
+	rootCmd.AddCommand(bestHeightCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var getTransactionCmd = &cobra.Command{
+	Use:   "gettransaction <txid>",
+	Short: "Print a confirmed transaction with its block and confirmations",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := hex.DecodeString(args[0])
+		if err != nil {
+			fmt.Println("❌ Invalid transaction id:", err)
+			return
+		}
+
+		bc := openBlockchain()
+		defer bc.Close()
+
+		res, err := bc.FindTransaction(id)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+
+		fmt.Printf("Block: %x (height %d, position %d)\n", res.BlockHash, res.BlockHeight, res.Position)
+		fmt.Println("Confirmations:", res.Confirmations)
+		out, _ := json.MarshalIndent(res.Transaction, "", "  ")
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(getTransactionCmd)
+}
@@ -2,23 +2,44 @@ package cmd
 
 import (
 	"github.com/Shubham0699/go-mini-blockchain/block"
+	"github.com/Shubham0699/go-mini-blockchain/p2p"
 	"github.com/Shubham0699/go-mini-blockchain/server"
 	"github.com/spf13/cobra"
 )
 
 var port string
 
+// P2P listen address and peers of the http command; without an address
+// blocks mined or submitted over HTTP are not relayed
+var (
+	p2pAddress string
+	peers      []string
+)
+
 var httpCmd = &cobra.Command{
 	Use:   "http",
 	Short: "Start HTTP server for blockchain",
 	Run: func(cmd *cobra.Command, args []string) {
-		bc := block.GetBlockchain()
-		s := server.NewServer(bc)
+		bc := openBlockchain()
+		bc.SetMiningWorkers(minerWorkers)
+
+		var broadcast func(*block.Block)
+		if p2pAddress != "" {
+			node := p2p.NewNode(p2pAddress, bc)
+			go node.StartServer()
+			for _, peer := range peers {
+				node.ConnectPeer(peer)
+			}
+			broadcast = node.BroadcastBlock
+		}
+		s := server.NewServer(bc, broadcast)
 		s.Start(port)
 	},
 }
 
 func init() {
 	httpCmd.Flags().StringVarP(&port, "port", "p", "8080", "Port to run HTTP server")
+	httpCmd.Flags().StringVar(&p2pAddress, "p2p", "", "address to accept peers on, e.g. localhost:3001; blocks from the API are relayed to them")
+	httpCmd.Flags().StringSliceVar(&peers, "peer", nil, "address of a peer to connect to with --p2p (repeatable)")
 	rootCmd.AddCommand(httpCmd)
 }
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Shubham0699/go-mini-blockchain/block"
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/consensus"
+	"github.com/Shubham0699/go-mini-blockchain/p2p"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+	"github.com/spf13/cobra"
+)
+
+// Hex private key this node seals proof-of-authority blocks with
+var signerKey string
+
+// Bootstrap file the history below a loaded UTXO set snapshot is checked against
+var history string
+
+func init() {
+	rootCmd.Flags().StringVar(&signerKey, "signer-key", "", "hex private key this node seals blocks with (poa)")
+	rootCmd.Flags().StringVar(&history, "history", "", "bootstrap file to validate the history below a loaded UTXO set snapshot with, in the background")
+}
+
+// runNode runs the node: the P2P server on localhost:3000, a block mined
+// every 10 seconds and a prompt for manual commands
+func runNode(cmd *cobra.Command, args []string) {
+	// Cancelled on Ctrl+C or "exit" so in-flight mining stops with the node
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Load blockchain
+	bc := openBlockchain()
+	defer bc.Close()
+	bc.SetMiningWorkers(minerWorkers)
+
+	poa, isPoA := bc.Engine().(*consensus.PoA)
+	if isPoA && signerKey != "" {
+		w, err := wallet.NewWalletFromHex(signerKey)
+		if err != nil {
+			log.Fatal("Invalid signer key: ", err)
+		}
+		poa.Authorize(w.Private)
+		log.Println("🔏 Sealing blocks as signer:", w.Address())
+	}
+
+	// A chain started from a UTXO set snapshot runs right away; its history
+	// is replayed next to it
+	if pending, ok := bc.PendingTxOutSet(); ok {
+		if history == "" {
+			log.Printf("⚠️ Running on a UTXO set snapshot at height %d whose history is not validated; pass --history <bootstrap file>", pending.Height)
+		} else {
+			go validateHistory(ctx, bc, history, block.DataPath(dataDir, chaincfg.ActiveNetParams)+".history")
+		}
+	}
+
+	// Start P2P node
+	node := p2p.NewNode("localhost:3000", bc)
+	go node.StartServer()
+
+	// Automatic mining every 10 seconds. A block from a peer moves the tip
+	// and aborts the current attempt, which is then restarted on the new tip.
+	go func() {
+		for {
+			w, _ := wallet.NewWallet()
+			cbTx := tx.NewCoinbaseTX(w.Address(), node.Blockchain.BlockSubsidy(), nextHeight(node.Blockchain), 0)
+			mined, err := node.Blockchain.MineBlockContext(ctx, []*tx.Transaction{cbTx})
+			if err == block.ErrStaleTip {
+				log.Println("⚠️ Tip changed while mining, restarting on the new tip")
+				continue
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return // node is shutting down
+				}
+				// e.g. not our turn to seal on a proof-of-authority chain
+				log.Println("⚠️ Could not seal block:", err)
+			} else {
+				node.BroadcastBlock(mined)
+
+				log.Println("✅ Auto-mined block to:", w.Address())
+				log.Println("⛏️", node.Blockchain.LastMiningStats())
+			}
+
+			select {
+			case <-time.After(10 * time.Second):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// CLI for manual commands, read in the background so Ctrl+C is noticed
+	lines := make(chan string)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return // stdin closed, keep running until Ctrl+C
+			}
+			lines <- input
+		}
+	}()
+
+	for {
+		fmt.Println("\nCommands: mine <address> | propose <address> <add|drop> | print | exit")
+		var input string
+		select {
+		case input = <-lines:
+		case <-ctx.Done():
+			fmt.Println("Shutting down...")
+			return
+		}
+		input = strings.TrimSpace(input)
+		args := strings.Split(input, " ")
+
+		switch args[0] {
+		case "mine":
+			if len(args) < 2 {
+				fmt.Println("Usage: mine <address>")
+				continue
+			}
+			address := args[1]
+			cbTx := tx.NewCoinbaseTX(address, node.Blockchain.BlockSubsidy(), nextHeight(node.Blockchain), 0)
+			mined, err := node.Blockchain.MineBlockContext(ctx, []*tx.Transaction{cbTx})
+			if err != nil {
+				fmt.Println("❌ Mining aborted:", err)
+				continue
+			}
+
+			node.BroadcastBlock(mined)
+
+			fmt.Println("✅ Mined block to:", address)
+			fmt.Println("⛏️", node.Blockchain.LastMiningStats())
+
+		case "propose":
+			if !isPoA || len(args) < 3 || (args[2] != "add" && args[2] != "drop") {
+				fmt.Println("Usage: propose <address> <add|drop> (proof-of-authority chains only)")
+				continue
+			}
+			poa.Propose(args[1], args[2] == "add")
+			fmt.Println("🗳️ Voting to", args[2], "signer", args[1])
+
+		case "print":
+			printBlockchain(node.Blockchain)
+
+		case "exit":
+			fmt.Println("Exiting...")
+			return
+
+		default:
+			fmt.Println("Unknown command")
+		}
+	}
+}
+
+// validateHistory checks the history below the loaded UTXO set snapshot of
+// bc against a bootstrap file. The replayed chain is kept in scratchPath
+// until it matches, so a node restarted midway resumes. A history that does
+// not match stops the node: its chain state cannot be trusted.
+func validateHistory(ctx context.Context, bc *block.Blockchain, path, scratchPath string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Println("❌ Could not validate the snapshot history:", err)
+		return
+	}
+	defer f.Close()
+	br, err := block.NewBootstrapReader(f)
+	if err != nil {
+		log.Println("❌ Could not validate the snapshot history:", err)
+		return
+	}
+
+	scratch, err := block.OpenBoltStore(scratchPath)
+	if err != nil {
+		log.Println("❌ Could not validate the snapshot history:", err)
+		return
+	}
+	err = bc.ValidateTxOutSet(ctx, br, scratch)
+	scratch.Close()
+	switch {
+	case err == nil:
+		os.Remove(scratchPath)
+	case errors.Is(err, block.ErrTxOutSetMismatch):
+		log.Fatal("❌ The UTXO set snapshot is invalid, start over from an empty data directory: ", err)
+	case ctx.Err() == nil:
+		log.Println("❌ Could not validate the snapshot history:", err)
+	}
+}
+
+// nextHeight is the height of a block mined on the current tip
+func nextHeight(bc *block.Blockchain) int64 {
+	return bc.BestHeight() + 1
+}
+
+func printBlockchain(bc *block.Blockchain) {
+	fmt.Println("\n📜 Blockchain History:")
+	blocks := bc.GetBlocks()
+	for _, b := range blocks {
+		fmt.Printf("---------------------------\n")
+		fmt.Printf("Hash: %x\n", b.Hash)
+		fmt.Printf("PrevHash: %x\n", b.PrevBlockHash)
+		fmt.Printf("Nonce: %d\n", b.Nonce)
+		fmt.Printf("Timestamp: %d\n", b.Timestamp)
+		if len(b.Transactions) > 0 {
+			fmt.Println("Transactions:")
+			for _, t := range b.Transactions {
+				fmt.Printf(" - TXID: %x\n", t.ID)
+				fmt.Printf("   Inputs: %+v\n", t.Vin)
+				fmt.Printf("   Outputs: %+v\n", t.Vout)
+			}
+		} else {
+			fmt.Printf("Data: %s\n", string(b.Data))
+		}
+	}
+	fmt.Println("---------------------------")
+}
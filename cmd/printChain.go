@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/Shubham0699/go-mini-blockchain/block"
 	"github.com/spf13/cobra"
 )
 
@@ -10,7 +9,7 @@ var printChainCmd = &cobra.Command{
 	Use:   "printchain",
 	Short: "Print all blocks in the blockchain",
 	Run: func(cmd *cobra.Command, args []string) {
-		bc := block.GetBlockchain()
+		bc := openBlockchain()
 		defer bc.Close() // important to close DB after use
 
 		it := bc.Iterator()
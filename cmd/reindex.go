@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the UTXO set from the stored blocks",
+	Run: func(cmd *cobra.Command, args []string) {
+		bc := openBlockchain()
+		defer bc.Close()
+
+		if err := bc.Reindex(); err != nil {
+			fmt.Println("❌ Reindex failed:", err)
+			bc.Close()
+			os.Exit(1)
+		}
+		fmt.Println("✅ UTXO set and height index rebuilt up to height", bc.BestHeight())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+}
@@ -3,14 +3,176 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/Shubham0699/go-mini-blockchain/block"
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "blockchain",
 	Short: "A simple blockchain CLI",
-	Long:  `This is a minimal blockchain written in Go with CLI commands.`,
+	Long: `This is a minimal blockchain written in Go with CLI commands.
+
+Without a command it runs the node: the P2P server on localhost:3000, a block
+mined every 10 seconds and a prompt for manual commands.`,
+	Args: cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyParamFlags(cmd)
+	},
+	Run: runNode,
+}
+
+// Number of goroutines used for mining (0 = one per CPU)
+var minerWorkers int
+
+// Directory holding one subdirectory per network
+var dataDir string
+
+// chain is opened on first use and shared by the pre-run hook and the command
+var chain *block.Blockchain
+
+// Consensus engine of a new chain and, for poa, its genesis signers
+var (
+	engine  string
+	signers []string
+)
+
+// Checkpoint, assume-valid and trusted snapshot overrides, as height:hash
+var (
+	checkpoints []string
+	assumeValid string
+	assumeUTXO  []string
+)
+
+// Block history to keep, see block.ParsePruneTarget
+var (
+	prune      string
+	pruneDepth int64
+)
+
+// Turn the optional indexes on or off when given
+var (
+	txIndex      bool
+	addrIndex    bool
+	setTxIndex   bool
+	setAddrIndex bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&dataDir, "datadir", block.DefaultDataDir(), "directory for chain data, one subdirectory per network")
+	rootCmd.PersistentFlags().IntVar(&minerWorkers, "miners", 0, "number of mining goroutines (0 = one per CPU)")
+	rootCmd.PersistentFlags().StringVar(&engine, "consensus", "pow", "consensus engine of a new chain: pow or poa")
+	rootCmd.PersistentFlags().StringSliceVar(&signers, "signers", nil, "genesis signer addresses (poa, comma separated or repeatable)")
+	rootCmd.PersistentFlags().StringSliceVar(&checkpoints, "checkpoint", nil, "height:hash checkpoint, replaces the built-in list (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&assumeValid, "assumevalid", "", "height:hash of the assume-valid block, or none to check every signature")
+	rootCmd.PersistentFlags().StringSliceVar(&assumeUTXO, "assumeutxo", nil, "height:hash of a trusted UTXO set snapshot, replaces the built-in list (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&txIndex, "txindex", false, "maintain an index of all transactions (stored, =false drops it)")
+	rootCmd.PersistentFlags().BoolVar(&addrIndex, "addrindex", false, "maintain an index of transactions by address (stored, =false drops it)")
+	rootCmd.PersistentFlags().StringVar(&prune, "prune", "", "delete old block bodies, keeping this many recent blocks or e.g. 550MB of them")
+	rootCmd.PersistentFlags().Int64Var(&pruneDepth, "prune-depth", block.DefaultPruneDepth, "recent blocks a pruned node keeps for reorgs")
+}
+
+// openBlockchain opens the active network's chain inside --datadir
+func openBlockchain() *block.Blockchain {
+	return openBlockchainWithGenesis(nil)
+}
+
+// openBlockchainWithGenesis is openBlockchain for commands that start a new
+// chain from a given genesis block (nil mines one). Index settings from
+// the command line and pruning are applied as the chain is opened.
+func openBlockchainWithGenesis(genesis *block.Block) *block.Blockchain {
+	if chain != nil {
+		return chain
+	}
+
+	var err error
+	chain, err = block.OpenWithGenesis(chainPath(), chaincfg.ActiveNetParams, genesis)
+	if err == nil {
+		err = applyChainFlags(chain)
+	}
+	if err != nil {
+		fmt.Println("❌ Could not open the blockchain:", err)
+		os.Exit(1)
+	}
+	return chain
+}
+
+// chainPath is the database file of the active network inside --datadir.
+// A chain left in ./blockchain.db by older versions is warned about.
+func chainPath() string {
+	path := block.DataPath(dataDir, chaincfg.ActiveNetParams)
+	block.WarnLegacyDatabase(path)
+	return path
+}
+
+// applyChainFlags applies the index and pruning settings from the command
+// line to an open chain
+func applyChainFlags(bc *block.Blockchain) error {
+	if setTxIndex {
+		if err := bc.SetTxIndex(txIndex); err != nil {
+			return err
+		}
+	}
+	if setAddrIndex {
+		if err := bc.SetAddrIndex(addrIndex); err != nil {
+			return err
+		}
+	}
+	if prune != "" {
+		target, err := block.ParsePruneTarget(prune, pruneDepth)
+		if err != nil {
+			return err
+		}
+		return bc.SetPrune(target)
+	}
+	return nil
+}
+
+// applyParamFlags copies the active chain parameters, or the
+// proof-of-authority ones with --consensus poa, with the checkpoint and
+// snapshot overrides from the command line and records the index settings
+func applyParamFlags(cmd *cobra.Command) error {
+	params := *chaincfg.ActiveNetParams
+	switch engine {
+	case "pow":
+	case "poa":
+		if len(signers) == 0 {
+			return fmt.Errorf("--consensus poa needs at least one address in --signers")
+		}
+		params = chaincfg.PoANetParams
+		params.Signers = make([]string, len(signers))
+		for i, signer := range signers {
+			if !wallet.IsAddress(signer) {
+				return fmt.Errorf("signer %q is not an address", signer)
+			}
+			params.Signers[i] = strings.ToLower(signer)
+		}
+	default:
+		return fmt.Errorf("unknown consensus engine %q, want pow or poa", engine)
+	}
+	if cmd.Flags().Changed("checkpoint") {
+		if err := params.SetCheckpoints(checkpoints); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("assumevalid") {
+		if err := params.SetAssumeValid(assumeValid); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("assumeutxo") {
+		if err := params.SetAssumeUTXO(assumeUTXO); err != nil {
+			return err
+		}
+	}
+	chaincfg.ActiveNetParams = &params
+
+	setTxIndex = cmd.Flags().Changed("txindex")
+	setAddrIndex = cmd.Flags().Changed("addrindex")
+	return nil
 }
 
 // Execute runs the root command
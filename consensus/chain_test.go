@@ -0,0 +1,94 @@
+package consensus
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+)
+
+// testHeader is a minimal Header; its hash covers the parent, time, bits
+// and nonce
+type testHeader struct {
+	prev      []byte
+	hash      []byte
+	timestamp int64
+	bits      uint32
+	nonce     int64
+	signer    []byte
+	signature []byte
+	vote      []byte
+	authorize bool
+}
+
+func (h *testHeader) PrevHash() []byte       { return h.prev }
+func (h *testHeader) TimestampUnix() int64   { return h.timestamp }
+func (h *testHeader) NonceValue() int64      { return h.nonce }
+func (h *testHeader) BitsValue() uint32      { return h.bits }
+func (h *testHeader) HashBytes() []byte      { return h.hash }
+func (h *testHeader) SetBits(bits uint32)    { h.bits = bits }
+func (h *testHeader) SetTimestamp(ts int64)  { h.timestamp = ts }
+func (h *testHeader) SignerKey() []byte      { return h.signer }
+func (h *testHeader) SignatureBytes() []byte { return h.signature }
+
+func (h *testHeader) SetSeal(nonce int64, hash []byte) { h.nonce, h.hash = nonce, hash }
+func (h *testHeader) SetSignature(signer, sig []byte)  { h.signer, h.signature = signer, sig }
+func (h *testHeader) VoteData() ([]byte, bool)         { return h.vote, h.authorize }
+func (h *testHeader) SetVote(target []byte, authorize bool) {
+	h.vote, h.authorize = target, authorize
+}
+
+func (h *testHeader) SerializeHeader() []byte {
+	buf := append([]byte{}, h.prev...)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(h.timestamp))
+	buf = binary.LittleEndian.AppendUint32(buf, h.bits)
+	return binary.LittleEndian.AppendUint64(buf, uint64(h.nonce))
+}
+
+// testChain is a ChainReader over headers kept in memory
+type testChain struct {
+	params  *chaincfg.Params
+	headers map[string]*testHeader
+	heights map[string]int64
+	tip     *testHeader
+}
+
+// newTestChain starts a chain with a genesis block at time 0
+func newTestChain(params *chaincfg.Params) *testChain {
+	genesis := &testHeader{bits: params.GenesisBits}
+	sum := sha256.Sum256(genesis.SerializeHeader())
+	genesis.hash = sum[:]
+	c := &testChain{
+		params:  params,
+		headers: make(map[string]*testHeader),
+		heights: make(map[string]int64),
+	}
+	c.insert(genesis)
+	return c
+}
+
+func (c *testChain) Params() *chaincfg.Params { return c.params }
+func (c *testChain) Height(hash []byte) int64 { return c.heights[string(hash)] }
+
+func (c *testChain) GetHeader(hash []byte) Header {
+	if h, ok := c.headers[string(hash)]; ok {
+		return h
+	}
+	return nil
+}
+
+// insert adds h, whose parent has to be known already, as the new tip
+func (c *testChain) insert(h *testHeader) {
+	height := int64(0)
+	if h.prev != nil {
+		height = c.heights[string(h.prev)] + 1
+	}
+	c.headers[string(h.hash)] = h
+	c.heights[string(h.hash)] = height
+	c.tip = h
+}
+
+// child returns an unsealed header on the tip at time timestamp
+func (c *testChain) child(timestamp int64) *testHeader {
+	return &testHeader{prev: c.tip.hash, timestamp: timestamp}
+}
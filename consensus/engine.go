@@ -0,0 +1,87 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+)
+
+// Errors returned by VerifyHeader. Engines wrap them with block details.
+var (
+	ErrUnknownParent        = errors.New("unknown parent block")
+	ErrUnexpectedDifficulty = errors.New("difficulty does not match the expected value")
+	ErrBadHash              = errors.New("hash does not match the block header")
+	ErrHighHash             = errors.New("hash is above the target")
+	ErrUnauthorizedSigner   = errors.New("signer is not authorised")
+	ErrRecentlySigned       = errors.New("signer has signed a recent block")
+	ErrBadSignature         = errors.New("invalid block signature")
+	ErrInvalidVote          = errors.New("invalid signer vote")
+	ErrTooSoon              = errors.New("block is sealed too soon after its parent")
+	ErrNotAuthorized        = errors.New("node has no authorised signing key")
+)
+
+// Header is the part of a block a consensus engine reads and seals.
+// It mirrors proof.BlockData so this package does not import block.
+type Header interface {
+	proof.BlockData
+	HashBytes() []byte
+
+	SetBits(bits uint32)
+	SetTimestamp(ts int64)
+	SetSeal(nonce int64, hash []byte)
+
+	// Proof-of-authority fields
+	SignerKey() []byte
+	SignatureBytes() []byte
+	SetSignature(signer, sig []byte)
+	VoteData() (target []byte, authorize bool)
+	SetVote(target []byte, authorize bool)
+}
+
+// ChainReader gives an engine access to the blocks it builds on
+type ChainReader interface {
+	Params() *chaincfg.Params
+	// GetHeader returns nil when the block is unknown
+	GetHeader(hash []byte) Header
+	// Height returns the number of blocks between hash and genesis
+	Height(hash []byte) int64
+}
+
+// Engine decides who may extend the chain and how blocks are sealed
+type Engine interface {
+	// Prepare fills in the consensus fields (difficulty, timestamp, vote)
+	// of a block that is about to be sealed on top of its parent
+	Prepare(chain ChainReader, header Header) error
+
+	// Seal produces the nonce or signature that makes the block valid.
+	// It returns ctx.Err() when ctx is cancelled first.
+	Seal(ctx context.Context, chain ChainReader, header Header) error
+
+	// VerifyHeader checks the consensus fields and seal of a block whose
+	// parent is already known
+	VerifyHeader(chain ChainReader, header Header) error
+
+	// Work is how much a block adds to the cumulative work of its chain;
+	// the chain with the most work is the active one
+	Work(header Header) *big.Int
+}
+
+// New returns the engine selected by the chain's genesis parameters
+func New(params *chaincfg.Params) Engine {
+	if params.Consensus == chaincfg.ProofOfAuthority {
+		return NewPoA(params)
+	}
+	return NewPoW()
+}
+
+func parentOf(chain ChainReader, header Header) (Header, error) {
+	parent := chain.GetHeader(header.PrevHash())
+	if parent == nil {
+		return nil, fmt.Errorf("%w %x", ErrUnknownParent, header.PrevHash())
+	}
+	return parent, nil
+}
@@ -0,0 +1,297 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+)
+
+// Block difficulty for in-turn and out-of-turn signers. The in-turn signer
+// adds more weight, so its block wins when both are sealed at once.
+const (
+	diffInTurn = 2
+	diffNoTurn = 1
+)
+
+// Extra delay per signer that out-of-turn signers wait before sealing
+const wiggleTime = 500 * time.Millisecond
+
+// Snapshots are kept in memory at this interval (and for recent lookups)
+const snapshotInterval = 64
+
+// PoA is a Clique-style proof-of-authority engine: authorised signers take
+// turns sealing blocks and vote signers in or out by majority
+type PoA struct {
+	params *chaincfg.Params
+
+	mu        sync.Mutex
+	key       *ecdsa.PrivateKey // our signing key, nil if not a signer
+	proposals map[string]bool   // address -> authorise (true) or drop
+
+	snapMu    sync.Mutex
+	snapshots map[string]*Snapshot // block hash -> state after that block
+}
+
+// NewPoA creates a proof-of-authority engine for params
+func NewPoA(params *chaincfg.Params) *PoA {
+	return &PoA{
+		params:    params,
+		proposals: make(map[string]bool),
+		snapshots: make(map[string]*Snapshot),
+	}
+}
+
+// Authorize sets the key this node seals blocks with
+func (e *PoA) Authorize(key *ecdsa.PrivateKey) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.key = key
+}
+
+// Propose adds a vote to authorise (or drop) address, cast in every block
+// this node seals until it passes or is discarded
+func (e *PoA) Propose(address string, authorize bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.proposals[address] = authorize
+}
+
+// Discard drops a pending proposal
+func (e *PoA) Discard(address string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.proposals, address)
+}
+
+// Signers returns the addresses authorised to seal the block after hash
+func (e *PoA) Signers(chain ChainReader, hash []byte) ([]string, error) {
+	snap, err := e.snapshot(chain, hash)
+	if err != nil {
+		return nil, err
+	}
+	return snap.SignerList(), nil
+}
+
+// Prepare sets the difficulty, timestamp and vote of a new block
+func (e *PoA) Prepare(chain ChainReader, header Header) error {
+	parent, err := parentOf(chain, header)
+	if err != nil {
+		return err
+	}
+	snap, err := e.snapshot(chain, parent.HashBytes())
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	header.SetBits(diffNoTurn)
+	if e.key != nil && snap.inturn(snap.Height+1, keyAddress(e.key)) {
+		header.SetBits(diffInTurn)
+	}
+
+	header.SetVote(nil, false)
+	for address, authorize := range e.proposals {
+		target, err := hex.DecodeString(address)
+		if err != nil || !snap.validVote(address, authorize) {
+			continue
+		}
+		header.SetVote(target, authorize)
+		break
+	}
+
+	// keep the assembled time unless the period has not passed yet
+	ts := parent.TimestampUnix() + e.params.Period
+	if assembled := header.TimestampUnix(); assembled > ts {
+		ts = assembled
+	}
+	header.SetTimestamp(ts)
+	return nil
+}
+
+// Seal waits for the block's time slot and signs it
+func (e *PoA) Seal(ctx context.Context, chain ChainReader, header Header) error {
+	e.mu.Lock()
+	key := e.key
+	e.mu.Unlock()
+	if key == nil {
+		return ErrNotAuthorized
+	}
+
+	parent, err := parentOf(chain, header)
+	if err != nil {
+		return err
+	}
+	snap, err := e.snapshot(chain, parent.HashBytes())
+	if err != nil {
+		return err
+	}
+
+	signer := keyAddress(key)
+	if _, ok := snap.Signers[signer]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnauthorizedSigner, signer)
+	}
+	if snap.recentlySigned(signer) {
+		return fmt.Errorf("%w: %s", ErrRecentlySigned, signer)
+	}
+
+	// out-of-turn signers hold back so the in-turn block usually wins
+	delay := time.Until(time.Unix(header.TimestampUnix(), 0))
+	if header.BitsValue() == diffNoTurn {
+		wiggle := time.Duration(len(snap.Signers)/2+1) * wiggleTime
+		delay += time.Duration(mrand.Int63n(int64(wiggle)))
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+	}
+
+	header.SetSeal(0, nil)
+	hash := proof.NewProofOfWork(header).Hash()
+	header.SetSeal(0, hash)
+
+	pub := wallet.PubKeyBytes(&key.PublicKey)
+	target, authorize := header.VoteData()
+	r, s, err := ecdsa.Sign(rand.Reader, key, sealDigest(hash, pub, target, authorize))
+	if err != nil {
+		return err
+	}
+	header.SetSignature(pub, append(wallet.PadTo32(r.Bytes()), wallet.PadTo32(s.Bytes())...))
+	return nil
+}
+
+// VerifyHeader checks the signer, its turn and the signature
+func (e *PoA) VerifyHeader(chain ChainReader, header Header) error {
+	parent, err := parentOf(chain, header)
+	if err != nil {
+		return err
+	}
+	if header.TimestampUnix() < parent.TimestampUnix()+e.params.Period {
+		return fmt.Errorf("%w: %d < %d + %d", ErrTooSoon,
+			header.TimestampUnix(), parent.TimestampUnix(), e.params.Period)
+	}
+
+	if header.NonceValue() != 0 || !bytes.Equal(proof.NewProofOfWork(header).Hash(), header.HashBytes()) {
+		return fmt.Errorf("%w: %x", ErrBadHash, header.HashBytes())
+	}
+
+	target, authorize := header.VoteData()
+	if len(target) != 0 && len(target) != 20 {
+		return fmt.Errorf("%w: target must be a 20 byte address", ErrInvalidVote)
+	}
+
+	pub := header.SignerKey()
+	if !verifySeal(pub, header.SignatureBytes(), sealDigest(header.HashBytes(), pub, target, authorize)) {
+		return fmt.Errorf("%w on block %x", ErrBadSignature, header.HashBytes())
+	}
+
+	snap, err := e.snapshot(chain, parent.HashBytes())
+	if err != nil {
+		return err
+	}
+	signer := wallet.AddressFromPubKey(pub)
+	if _, ok := snap.Signers[signer]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnauthorizedSigner, signer)
+	}
+	if snap.recentlySigned(signer) {
+		return fmt.Errorf("%w: %s", ErrRecentlySigned, signer)
+	}
+
+	want := uint32(diffNoTurn)
+	if snap.inturn(snap.Height+1, signer) {
+		want = diffInTurn
+	}
+	if header.BitsValue() != want {
+		return fmt.Errorf("%w: block has %d, expected %d", ErrUnexpectedDifficulty, header.BitsValue(), want)
+	}
+	return nil
+}
+
+// Work is the block difficulty, so in-turn blocks weigh more (as in Clique)
+func (e *PoA) Work(header Header) *big.Int {
+	return big.NewInt(int64(header.BitsValue()))
+}
+
+// snapshot returns the signer state after the block with hash, replaying
+// blocks forward from the nearest cached snapshot or from genesis
+func (e *PoA) snapshot(chain ChainReader, hash []byte) (*Snapshot, error) {
+	e.snapMu.Lock()
+	defer e.snapMu.Unlock()
+
+	var headers []Header
+	var snap *Snapshot
+	for snap == nil {
+		if s, ok := e.snapshots[string(hash)]; ok {
+			snap = s
+			break
+		}
+
+		h := chain.GetHeader(hash)
+		if h == nil {
+			return nil, fmt.Errorf("%w %x", ErrUnknownParent, hash)
+		}
+		if len(h.PrevHash()) == 0 {
+			snap = newSnapshot(hash, e.params.Signers)
+			e.snapshots[string(hash)] = snap
+			break
+		}
+		headers = append(headers, h)
+		hash = h.PrevHash()
+	}
+
+	for i := len(headers) - 1; i >= 0; i-- {
+		snap = snap.apply(headers[i], wallet.AddressFromPubKey(headers[i].SignerKey()), e.params.Epoch)
+		if snap.Height%snapshotInterval == 0 || i == 0 {
+			e.snapshots[string(snap.Hash)] = snap
+		}
+	}
+	return snap, nil
+}
+
+// sealDigest is what a signer signs: the block hash plus the seal fields
+// that are not part of the hash
+func sealDigest(hash, signer, voteTarget []byte, authorize bool) []byte {
+	buf := append([]byte{}, hash...)
+	buf = append(buf, signer...)
+	buf = append(buf, voteTarget...)
+	if authorize {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	h := sha256.Sum256(buf)
+	return h[:]
+}
+
+func verifySeal(pub, sig, digest []byte) bool {
+	if len(pub) != 64 || len(sig) != 64 {
+		return false
+	}
+	key := ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(pub[:32]),
+		Y:     new(big.Int).SetBytes(pub[32:]),
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	return ecdsa.Verify(&key, digest, r, s)
+}
+
+func keyAddress(key *ecdsa.PrivateKey) string {
+	return wallet.AddressFromPubKey(wallet.PubKeyBytes(&key.PublicKey))
+}
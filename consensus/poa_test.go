@@ -0,0 +1,179 @@
+package consensus
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+)
+
+// newPoATest starts a chain signed by n new keys, returned in the order of
+// the signer list, so key i is in turn at every height h with h%n == i
+func newPoATest(t *testing.T, n int) (*testChain, []*ecdsa.PrivateKey) {
+	t.Helper()
+	byAddress := make(map[string]*ecdsa.PrivateKey)
+	params := chaincfg.PoANetParams
+	params.Period = 1
+	params.Signers = nil
+	for i := 0; i < n; i++ {
+		w, err := wallet.NewWallet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		byAddress[w.Address()] = w.Private
+		params.Signers = append(params.Signers, w.Address())
+	}
+
+	keys := make([]*ecdsa.PrivateKey, 0, n)
+	for _, address := range newSnapshot(nil, params.Signers).SignerList() {
+		keys = append(keys, byAddress[address])
+	}
+	return newTestChain(&params), keys
+}
+
+// sealAs prepares and seals a block on the tip of c with key
+func sealAs(c *testChain, key *ecdsa.PrivateKey) (*testHeader, error) {
+	e := NewPoA(c.params)
+	e.Authorize(key)
+	h := c.child(0)
+	if err := e.Prepare(c, h); err != nil {
+		return nil, err
+	}
+	return h, e.Seal(context.Background(), c, h)
+}
+
+// signAs seals h with key the way Seal does, without any of its checks
+func signAs(t *testing.T, h *testHeader, key *ecdsa.PrivateKey) {
+	t.Helper()
+	h.SetSeal(0, nil)
+	h.SetSeal(0, proof.NewProofOfWork(h).Hash())
+	pub := wallet.PubKeyBytes(&key.PublicKey)
+	r, s, err := ecdsa.Sign(rand.Reader, key, sealDigest(h.hash, pub, h.vote, h.authorize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetSignature(pub, append(wallet.PadTo32(r.Bytes()), wallet.PadTo32(s.Bytes())...))
+}
+
+func TestPoASealInTurnAndOutOfTurn(t *testing.T) {
+	c, keys := newPoATest(t, 3)
+	e := NewPoA(c.params)
+
+	// keys[1] is in turn at height 1, keys[0] is not at height 2
+	for _, tt := range []struct {
+		key  *ecdsa.PrivateKey
+		bits uint32
+	}{{keys[1], diffInTurn}, {keys[0], diffNoTurn}} {
+		h, err := sealAs(c, tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.bits != tt.bits {
+			t.Errorf("height %d: sealed with difficulty %d, want %d", c.Height(c.tip.hash)+1, h.bits, tt.bits)
+		}
+		if h.timestamp < c.tip.timestamp+c.params.Period {
+			t.Errorf("sealed at %d, less than a period after %d", h.timestamp, c.tip.timestamp)
+		}
+		if err := e.VerifyHeader(c, h); err != nil {
+			t.Fatal(err)
+		}
+		c.insert(h)
+	}
+
+	if e.Work(&testHeader{bits: diffInTurn}).Cmp(e.Work(&testHeader{bits: diffNoTurn})) <= 0 {
+		t.Error("an in-turn block does not outweigh an out-of-turn one")
+	}
+}
+
+func TestPoARejectsRecentSigners(t *testing.T) {
+	c, keys := newPoATest(t, 3)
+	e := NewPoA(c.params)
+	h, err := sealAs(c, keys[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.insert(h)
+
+	// with 3 signers keys[1] has to sit out one block
+	if _, err := sealAs(c, keys[1]); !errors.Is(err, ErrRecentlySigned) {
+		t.Errorf("sealing twice in a row: got %v, want ErrRecentlySigned", err)
+	}
+	forged := c.child(c.tip.timestamp + 1)
+	forged.bits = diffNoTurn
+	signAs(t, forged, keys[1])
+	if err := e.VerifyHeader(c, forged); !errors.Is(err, ErrRecentlySigned) {
+		t.Errorf("block signed twice in a row: got %v, want ErrRecentlySigned", err)
+	}
+
+	// after keys[2] signs it may sign again
+	h, err = sealAs(c, keys[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.insert(h)
+	if h, err = sealAs(c, keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.VerifyHeader(c, h); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPoAVerifyHeaderChecksSeal(t *testing.T) {
+	c, keys := newPoATest(t, 2)
+	e := NewPoA(c.params)
+	outsider, err := wallet.NewWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys[1] is in turn at height 1
+	valid := func() *testHeader {
+		h := c.child(c.tip.timestamp + 1)
+		h.bits = diffInTurn
+		signAs(t, h, keys[1])
+		return h
+	}
+	if err := e.VerifyHeader(c, valid()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(h *testHeader)
+		want   error
+	}{
+		{"tampered signature", func(h *testHeader) { h.signature[5] ^= 1 }, ErrBadSignature},
+		{"signature of another block", func(h *testHeader) {
+			other := valid()
+			other.timestamp++
+			signAs(t, other, keys[1])
+			h.signature = other.signature
+		}, ErrBadSignature},
+		{"vote added after signing", func(h *testHeader) { h.vote = make([]byte, 20) }, ErrBadSignature},
+		{"changed header", func(h *testHeader) { h.timestamp++ }, ErrBadHash},
+		{"nonce", func(h *testHeader) { h.nonce = 1; h.hash = proof.NewProofOfWork(h).Hash() }, ErrBadHash},
+		{"unauthorised signer", func(h *testHeader) { signAs(t, h, outsider.Private) }, ErrUnauthorizedSigner},
+		{"out-of-turn difficulty", func(h *testHeader) { h.bits = diffNoTurn; signAs(t, h, keys[1]) }, ErrUnexpectedDifficulty},
+		{"in-turn difficulty out of turn", func(h *testHeader) { signAs(t, h, keys[0]) }, ErrUnexpectedDifficulty},
+		{"too soon", func(h *testHeader) { h.timestamp = c.tip.timestamp; signAs(t, h, keys[1]) }, ErrTooSoon},
+		{"bad vote", func(h *testHeader) { h.vote = []byte{1}; signAs(t, h, keys[1]) }, ErrInvalidVote},
+	}
+	for _, tt := range tests {
+		h := valid()
+		tt.change(h)
+		if err := e.VerifyHeader(c, h); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	unauthorised := NewPoA(c.params)
+	if err := unauthorised.Seal(context.Background(), c, valid()); !errors.Is(err, ErrNotAuthorized) {
+		t.Errorf("sealing without a key: got %v, want ErrNotAuthorized", err)
+	}
+}
@@ -0,0 +1,125 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/Shubham0699/go-mini-blockchain/proof"
+)
+
+// PoW is the proof-of-work engine: blocks are sealed by searching for a
+// nonce whose hash is below the target set by the block's bits
+type PoW struct {
+	mu      sync.Mutex
+	workers int               // goroutines used to mine (0 = one per CPU)
+	stats   proof.MiningStats // stats of the last sealed block
+}
+
+// NewPoW creates a proof-of-work engine
+func NewPoW() *PoW {
+	return &PoW{}
+}
+
+// SetWorkers sets how many goroutines search for nonces (0 = one per CPU)
+func (e *PoW) SetWorkers(n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.workers = n
+}
+
+// LastStats returns hashrate and timing for the last sealed block
+func (e *PoW) LastStats() proof.MiningStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+// Prepare sets the difficulty required on top of the parent
+func (e *PoW) Prepare(chain ChainReader, header Header) error {
+	parent, err := parentOf(chain, header)
+	if err != nil {
+		return err
+	}
+	header.SetBits(CalcNextBits(chain, parent))
+	return nil
+}
+
+// Seal runs the nonce search
+func (e *PoW) Seal(ctx context.Context, chain ChainReader, header Header) error {
+	e.mu.Lock()
+	workers := e.workers
+	e.mu.Unlock()
+
+	pow := proof.NewProofOfWork(header)
+	pow.Workers = workers
+	nonce, hash, err := pow.Run(ctx)
+
+	e.mu.Lock()
+	e.stats = pow.Stats
+	e.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	header.SetSeal(nonce, hash)
+	return nil
+}
+
+// VerifyHeader checks the retarget rule and the proof of work
+func (e *PoW) VerifyHeader(chain ChainReader, header Header) error {
+	parent, err := parentOf(chain, header)
+	if err != nil {
+		return err
+	}
+
+	if want := CalcNextBits(chain, parent); header.BitsValue() != want {
+		return fmt.Errorf("%w: block has %d bits, expected %d",
+			ErrUnexpectedDifficulty, header.BitsValue(), want)
+	}
+
+	pow := proof.NewProofOfWork(header)
+	if !bytes.Equal(pow.Hash(), header.HashBytes()) {
+		return fmt.Errorf("%w: %x", ErrBadHash, header.HashBytes())
+	}
+	if !pow.Validate() {
+		return fmt.Errorf("%w: %x", ErrHighHash, header.HashBytes())
+	}
+	return nil
+}
+
+// Work is the expected number of hashes needed to meet the target: 2^bits
+func (e *PoW) Work(header Header) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(header.BitsValue()))
+}
+
+// CalcNextBits returns the difficulty required for the block after parent.
+// Bits only change on retarget boundaries, based on how long the last
+// RetargetInterval blocks took compared to the configured target spacing.
+func CalcNextBits(chain ChainReader, parent Header) uint32 {
+	params := chain.Params()
+
+	height := chain.Height(parent.HashBytes()) + 1
+	if height%params.RetargetInterval != 0 {
+		return parent.BitsValue()
+	}
+
+	// walk back to the first block of the window
+	first := parent
+	for i := int64(1); i < params.RetargetInterval; i++ {
+		first = chain.GetHeader(first.PrevHash())
+	}
+
+	actual := parent.TimestampUnix() - first.TimestampUnix()
+	bits := proof.RetargetBits(parent.BitsValue(), actual, params.TargetTimespan())
+
+	if bits < params.MinBits {
+		bits = params.MinBits
+	}
+	if bits > params.MaxBits {
+		bits = params.MaxBits
+	}
+	return bits
+}
@@ -0,0 +1,125 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shubham0699/go-mini-blockchain/chaincfg"
+)
+
+// powParams retarget every 5 blocks of 10 seconds, so a window spans 40
+func powParams() *chaincfg.Params {
+	p := chaincfg.MainNetParams
+	p.GenesisBits, p.MinBits, p.MaxBits = 4, 2, 6
+	p.TargetSpacing = 10
+	p.RetargetInterval = 5
+	return &p
+}
+
+// mineTestChain extends c by n blocks spacing seconds apart
+func mineTestChain(t *testing.T, e *PoW, c *testChain, n int, spacing int64) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		h := c.child(c.tip.timestamp + spacing)
+		if err := e.Prepare(c, h); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Seal(context.Background(), c, h); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.VerifyHeader(c, h); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+		c.insert(h)
+	}
+}
+
+func TestCalcNextBits(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  int   // blocks mined on genesis
+		spacing int64 // seconds between them
+		bits    uint32
+		want    uint32
+	}{
+		{"between retargets", 3, 1, 4, 4},
+		{"on target", 4, 10, 4, 4},
+		{"2x too fast", 4, 5, 4, 5},
+		{"4x too fast", 4, 2, 4, 6},
+		{"far too fast is capped at 2 bits", 4, 1, 3, 5},
+		{"2x too slow", 4, 20, 4, 3},
+		{"4x too slow", 4, 40, 4, 2},
+		{"far too slow is capped at 2 bits", 4, 1000, 6, 4},
+		{"clamped to MaxBits", 4, 1, 5, 6},
+		{"clamped to MinBits", 4, 1000, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := powParams()
+			params.GenesisBits = tt.bits
+			c := newTestChain(params)
+			mineTestChain(t, NewPoW(), c, tt.blocks, tt.spacing)
+			if got := CalcNextBits(c, c.tip); got != tt.want {
+				t.Errorf("next bits at height %d: got %d, want %d", tt.blocks+1, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoWRetargetAcrossWindows(t *testing.T) {
+	e := NewPoW()
+	c := newTestChain(powParams())
+
+	// a fast window raises the difficulty at height 5, a slow one at 10
+	// lowers it again; the bits in between stay put
+	mineTestChain(t, e, c, 5, 5)
+	if c.tip.bits != 5 {
+		t.Errorf("bits at height 5: got %d, want 5", c.tip.bits)
+	}
+	mineTestChain(t, e, c, 4, 20)
+	if c.tip.bits != 5 {
+		t.Errorf("bits at height 9: got %d, want 5", c.tip.bits)
+	}
+	mineTestChain(t, e, c, 1, 20)
+	if c.tip.bits != 4 {
+		t.Errorf("bits at height 10: got %d, want 4", c.tip.bits)
+	}
+}
+
+func TestPoWVerifyHeader(t *testing.T) {
+	e := NewPoW()
+	c := newTestChain(powParams())
+	mineTestChain(t, e, c, 4, 2)
+
+	seal := func(bits uint32) *testHeader {
+		h := c.child(c.tip.timestamp + 2)
+		h.bits = bits
+		if err := e.Seal(context.Background(), c, h); err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	// the retarget at height 5 asks for 6 bits
+	for _, bits := range []uint32{4, 5, 7} {
+		if err := e.VerifyHeader(c, seal(bits)); !errors.Is(err, ErrUnexpectedDifficulty) {
+			t.Errorf("%d bits: got %v, want ErrUnexpectedDifficulty", bits, err)
+		}
+	}
+
+	h := seal(6)
+	if err := e.VerifyHeader(c, h); err != nil {
+		t.Fatal(err)
+	}
+	h.timestamp++
+	if err := e.VerifyHeader(c, h); !errors.Is(err, ErrBadHash) {
+		t.Errorf("changed header: got %v, want ErrBadHash", err)
+	}
+
+	orphan := seal(6)
+	orphan.prev = []byte{1}
+	if err := e.VerifyHeader(c, orphan); !errors.Is(err, ErrUnknownParent) {
+		t.Errorf("unknown parent: got %v, want ErrUnknownParent", err)
+	}
+}
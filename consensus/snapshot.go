@@ -0,0 +1,195 @@
+package consensus
+
+import (
+	"encoding/hex"
+	"sort"
+)
+
+// Vote is a signer's proposal to add or remove an address
+type Vote struct {
+	Signer    string
+	Address   string
+	Authorize bool
+}
+
+// Tally counts the votes currently standing for one address
+type Tally struct {
+	Authorize bool
+	Votes     int
+}
+
+// Snapshot is the proof-of-authority state after a given block: who may
+// sign, who signed recently and which votes are still open
+type Snapshot struct {
+	Hash    []byte
+	Height  int64
+	Signers map[string]struct{}
+	Recents map[int64]string // height -> signer
+	Votes   []Vote
+	Tally   map[string]Tally
+}
+
+func newSnapshot(hash []byte, signers []string) *Snapshot {
+	s := &Snapshot{
+		Hash:    hash,
+		Signers: make(map[string]struct{}),
+		Recents: make(map[int64]string),
+		Tally:   make(map[string]Tally),
+	}
+	for _, signer := range signers {
+		s.Signers[signer] = struct{}{}
+	}
+	return s
+}
+
+func (s *Snapshot) copy() *Snapshot {
+	cpy := &Snapshot{
+		Hash:    s.Hash,
+		Height:  s.Height,
+		Signers: make(map[string]struct{}),
+		Recents: make(map[int64]string),
+		Votes:   append([]Vote(nil), s.Votes...),
+		Tally:   make(map[string]Tally),
+	}
+	for k := range s.Signers {
+		cpy.Signers[k] = struct{}{}
+	}
+	for k, v := range s.Recents {
+		cpy.Recents[k] = v
+	}
+	for k, v := range s.Tally {
+		cpy.Tally[k] = v
+	}
+	return cpy
+}
+
+// SignerList returns the authorised signers in ascending order
+func (s *Snapshot) SignerList() []string {
+	list := make([]string, 0, len(s.Signers))
+	for signer := range s.Signers {
+		list = append(list, signer)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// recentLimit is how many blocks a signer has to wait before signing again
+func (s *Snapshot) recentLimit() int64 {
+	return int64(len(s.Signers)/2 + 1)
+}
+
+// inturn reports whether signer is the designated signer at height
+func (s *Snapshot) inturn(height int64, signer string) bool {
+	signers := s.SignerList()
+	if len(signers) == 0 {
+		return false
+	}
+	return signers[height%int64(len(signers))] == signer
+}
+
+// recentlySigned reports whether signer may not seal the next block yet
+func (s *Snapshot) recentlySigned(signer string) bool {
+	next := s.Height + 1
+	for seen, recent := range s.Recents {
+		if recent == signer && seen > next-s.recentLimit() {
+			return true
+		}
+	}
+	return false
+}
+
+// validVote reports whether a vote would change the signer set
+func (s *Snapshot) validVote(address string, authorize bool) bool {
+	_, signer := s.Signers[address]
+	return (signer && !authorize) || (!signer && authorize)
+}
+
+func (s *Snapshot) cast(address string, authorize bool) {
+	t := s.Tally[address]
+	t.Authorize = authorize
+	t.Votes++
+	s.Tally[address] = t
+}
+
+func (s *Snapshot) uncast(address string, authorize bool) {
+	t, ok := s.Tally[address]
+	if !ok || t.Authorize != authorize {
+		return
+	}
+	if t.Votes > 1 {
+		t.Votes--
+		s.Tally[address] = t
+	} else {
+		delete(s.Tally, address)
+	}
+}
+
+// apply returns the snapshot after a block sealed by signer at the next
+// height. The header must already have been verified against s.
+func (s *Snapshot) apply(header Header, signer string, epoch int64) *Snapshot {
+	snap := s.copy()
+	snap.Hash = header.HashBytes()
+	snap.Height = s.Height + 1
+
+	// open votes are dropped at every epoch boundary
+	if epoch > 0 && snap.Height%epoch == 0 {
+		snap.Votes = nil
+		snap.Tally = make(map[string]Tally)
+	}
+
+	delete(snap.Recents, snap.Height-snap.recentLimit())
+	snap.Recents[snap.Height] = signer
+
+	target, authorize := header.VoteData()
+	if len(target) == 0 {
+		return snap
+	}
+	address := hex.EncodeToString(target)
+
+	// a signer's new vote on an address replaces its old one
+	for i, v := range snap.Votes {
+		if v.Signer == signer && v.Address == address {
+			snap.uncast(v.Address, v.Authorize)
+			snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+			break
+		}
+	}
+	if snap.validVote(address, authorize) {
+		snap.cast(address, authorize)
+		snap.Votes = append(snap.Votes, Vote{Signer: signer, Address: address, Authorize: authorize})
+	}
+
+	// a strict majority changes the signer set
+	if t := snap.Tally[address]; t.Votes > len(snap.Signers)/2 {
+		if t.Authorize {
+			snap.Signers[address] = struct{}{}
+		} else {
+			delete(snap.Signers, address)
+
+			// the shrunk window may free the oldest recent signer
+			delete(snap.Recents, snap.Height-snap.recentLimit())
+
+			// votes cast by the removed signer no longer count
+			kept := snap.Votes[:0]
+			for _, v := range snap.Votes {
+				if v.Signer == address {
+					snap.uncast(v.Address, v.Authorize)
+					continue
+				}
+				kept = append(kept, v)
+			}
+			snap.Votes = kept
+		}
+
+		// the proposal passed, so every vote on it is settled
+		kept := snap.Votes[:0]
+		for _, v := range snap.Votes {
+			if v.Address != address {
+				kept = append(kept, v)
+			}
+		}
+		snap.Votes = kept
+		delete(snap.Tally, address)
+	}
+	return snap
+}
@@ -4,11 +4,11 @@ go 1.18
 
 require (
 	github.com/boltdb/bolt v1.3.1
+	github.com/gorilla/websocket v1.5.3
 	github.com/spf13/cobra v1.9.1
 )
 
 require (
-	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.7 // indirect
 	golang.org/x/sys v0.35.0 // indirect
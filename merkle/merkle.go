@@ -0,0 +1,125 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// Leaves and interior nodes are hashed with different prefixes so an
+// interior node can never be passed off as a leaf (second-preimage attack).
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// ErrIndexOutOfRange is returned when a proof is requested for a missing leaf
+var ErrIndexOutOfRange = errors.New("merkle: leaf index out of range")
+
+// Tree is a binary hash tree stored level by level, leaves first
+type Tree struct {
+	levels [][][]byte
+}
+
+// ProofStep is one sibling hash on the path from a leaf to the root.
+// Left reports whether the sibling sits on the left of the running hash.
+type ProofStep struct {
+	Hash []byte
+	Left bool
+}
+
+// Proof shows that a single leaf is included under a Merkle root
+type Proof struct {
+	Index int
+	Steps []ProofStep
+}
+
+func hashLeaf(data []byte) []byte {
+	h := sha256.Sum256(append([]byte{leafPrefix}, data...))
+	return h[:]
+}
+
+func hashNode(left, right []byte) []byte {
+	buf := make([]byte, 0, 1+len(left)+len(right))
+	buf = append(buf, nodePrefix)
+	buf = append(buf, left...)
+	buf = append(buf, right...)
+	h := sha256.Sum256(buf)
+	return h[:]
+}
+
+// NewTree builds a Merkle tree over data. An odd node at the end of a level
+// is promoted unchanged instead of being paired with a copy of itself.
+func NewTree(data [][]byte) *Tree {
+	t := &Tree{}
+	if len(data) == 0 {
+		return t
+	}
+
+	level := make([][]byte, len(data))
+	for i, d := range data {
+		level[i] = hashLeaf(d)
+	}
+	t.levels = append(t.levels, level)
+
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashNode(level[i], level[i+1]))
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t
+}
+
+// Root returns the root hash, or the hash of an empty input for an empty tree
+func (t *Tree) Root() []byte {
+	if len(t.levels) == 0 {
+		h := sha256.Sum256([]byte{})
+		return h[:]
+	}
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Proof builds an inclusion proof for the leaf at index
+func (t *Tree) Proof(index int) (*Proof, error) {
+	if len(t.levels) == 0 || index < 0 || index >= len(t.levels[0]) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	p := &Proof{Index: index}
+	pos := index
+	for _, level := range t.levels[:len(t.levels)-1] {
+		if pos%2 == 1 {
+			p.Steps = append(p.Steps, ProofStep{Hash: level[pos-1], Left: true})
+		} else if pos+1 < len(level) {
+			p.Steps = append(p.Steps, ProofStep{Hash: level[pos+1], Left: false})
+		}
+		// a promoted odd node has no sibling on this level
+		pos /= 2
+	}
+	return p, nil
+}
+
+// Verify checks that data hashes up to root along the proof path
+func (p *Proof) Verify(root, data []byte) bool {
+	h := hashLeaf(data)
+	for _, step := range p.Steps {
+		if step.Left {
+			h = hashNode(step.Hash, h)
+		} else {
+			h = hashNode(h, step.Hash)
+		}
+	}
+	return bytes.Equal(h, root)
+}
+
+// Root is a shortcut for NewTree(data).Root()
+func Root(data [][]byte) []byte {
+	return NewTree(data).Root()
+}
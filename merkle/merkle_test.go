@@ -0,0 +1,134 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func leaves(n int) [][]byte {
+	data := make([][]byte, n)
+	for i := range data {
+		data[i] = []byte(fmt.Sprintf("tx-%d", i))
+	}
+	return data
+}
+
+func sum(parts ...[]byte) []byte {
+	h := sha256.Sum256(bytes.Join(parts, nil))
+	return h[:]
+}
+
+func TestRootLayout(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+	la, lb, lc := sum([]byte{0}, a), sum([]byte{0}, b), sum([]byte{0}, c)
+
+	tests := []struct {
+		name string
+		data [][]byte
+		want []byte
+	}{
+		{"empty", nil, sum()},
+		{"one leaf", [][]byte{a}, la},
+		{"two leaves", [][]byte{a, b}, sum([]byte{1}, la, lb)},
+		// the odd leaf is promoted, not paired with a copy of itself
+		{"three leaves", [][]byte{a, b, c}, sum([]byte{1}, sum([]byte{1}, la, lb), lc)},
+	}
+	for _, tt := range tests {
+		if got := Root(tt.data); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: root %x, want %x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRootSeparatesLeavesFromNodes(t *testing.T) {
+	a, b := []byte("a"), []byte("b")
+	two := Root([][]byte{a, b})
+	// an interior node presented as a single leaf must not give the same root
+	forged := Root([][]byte{append(sum([]byte{0}, a), sum([]byte{0}, b)...)})
+	if bytes.Equal(two, forged) {
+		t.Fatal("interior node hashes like a leaf")
+	}
+}
+
+func TestProofVerifies(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		data := leaves(n)
+		tree := NewTree(data)
+		for i := range data {
+			p, err := tree.Proof(i)
+			if err != nil {
+				t.Fatalf("n=%d: Proof(%d): %v", n, i, err)
+			}
+			if !p.Verify(tree.Root(), data[i]) {
+				t.Errorf("n=%d: proof for leaf %d does not verify", n, i)
+			}
+		}
+	}
+}
+
+func TestProofRejectsTampering(t *testing.T) {
+	data := leaves(7)
+	tree := NewTree(data)
+	root := tree.Root()
+
+	for i := range data {
+		p, err := tree.Proof(i)
+		if err != nil {
+			t.Fatalf("Proof(%d): %v", i, err)
+		}
+		other := data[(i+1)%len(data)]
+		if p.Verify(root, other) {
+			t.Errorf("leaf %d: proof accepts the data of another leaf", i)
+		}
+		if p.Verify(root, append(append([]byte(nil), data[i]...), 'x')) {
+			t.Errorf("leaf %d: proof accepts altered data", i)
+		}
+		if p.Verify(sum([]byte("another root")), data[i]) {
+			t.Errorf("leaf %d: proof accepts a different root", i)
+		}
+
+		for s := range p.Steps {
+			flipped := clone(p)
+			flipped.Steps[s].Hash[0] ^= 0xff
+			if flipped.Verify(root, data[i]) {
+				t.Errorf("leaf %d: proof accepts a changed hash at step %d", i, s)
+			}
+
+			swapped := clone(p)
+			swapped.Steps[s].Left = !swapped.Steps[s].Left
+			if swapped.Verify(root, data[i]) {
+				t.Errorf("leaf %d: proof accepts a swapped side at step %d", i, s)
+			}
+		}
+
+		if len(p.Steps) > 0 {
+			short := clone(p)
+			short.Steps = short.Steps[:len(short.Steps)-1]
+			if short.Verify(root, data[i]) {
+				t.Errorf("leaf %d: proof accepts a missing step", i)
+			}
+		}
+	}
+}
+
+func TestProofIndexOutOfRange(t *testing.T) {
+	tree := NewTree(leaves(3))
+	for _, i := range []int{-1, 3, 100} {
+		if _, err := tree.Proof(i); err != ErrIndexOutOfRange {
+			t.Errorf("Proof(%d): got %v, want ErrIndexOutOfRange", i, err)
+		}
+	}
+	if _, err := NewTree(nil).Proof(0); err != ErrIndexOutOfRange {
+		t.Errorf("empty tree: got %v, want ErrIndexOutOfRange", err)
+	}
+}
+
+func clone(p *Proof) *Proof {
+	c := &Proof{Index: p.Index, Steps: make([]ProofStep, len(p.Steps))}
+	for i, s := range p.Steps {
+		c.Steps[i] = ProofStep{Hash: append([]byte(nil), s.Hash...), Left: s.Left}
+	}
+	return c
+}
@@ -2,8 +2,11 @@ package p2p
 
 import (
 	"log"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/Shubham0699/go-mini-blockchain/block"
 	"github.com/gorilla/websocket"
@@ -31,11 +34,13 @@ func NewNode(address string, bc *block.Blockchain) *Node {
 
 // Connect to a peer
 func (n *Node) ConnectPeer(peerAddr string) {
-	ws, _, err := websocket.DefaultDialer.Dial("ws://"+peerAddr+"/ws", nil)
+	ws, resp, err := websocket.DefaultDialer.Dial("ws://"+peerAddr+"/ws", n.handshakeHeader())
 	if err != nil {
 		log.Println("Failed to connect to peer:", err)
 		return
 	}
+	n.addTimeSample(ws, resp.Header)
+	logPrunedPeer(peerAddr, resp.Header)
 	n.Mutex.Lock()
 	n.Peers[peerAddr] = ws
 	n.Mutex.Unlock()
@@ -45,11 +50,13 @@ func (n *Node) ConnectPeer(peerAddr string) {
 
 // Handle incoming peer connections
 func (n *Node) PeerHandler(w http.ResponseWriter, r *http.Request) {
-	ws, err := upgrader.Upgrade(w, r, nil)
+	ws, err := upgrader.Upgrade(w, r, n.handshakeHeader())
 	if err != nil {
 		log.Println("Failed to upgrade websocket:", err)
 		return
 	}
+	n.addTimeSample(ws, r.Header)
+	logPrunedPeer(ws.RemoteAddr().String(), r.Header)
 	n.Mutex.Lock()
 	n.Peers[ws.RemoteAddr().String()] = ws
 	n.Mutex.Unlock()
@@ -57,29 +64,83 @@ func (n *Node) PeerHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("✅ New peer connected:", ws.RemoteAddr().String())
 }
 
+// pruneHeightHeader advertises in the handshake that a node pruned its
+// block bodies up to the given height; such a node cannot serve them
+const pruneHeightHeader = "X-Prune-Height"
+
+// handshakeHeader carries our clock in the websocket handshake so both
+// sides can sample each other's time, and our prune height if we pruned
+func (n *Node) handshakeHeader() http.Header {
+	h := http.Header{}
+	h.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	if pruned := n.Blockchain.PruneHeight(); pruned > 0 {
+		h.Set(pruneHeightHeader, strconv.FormatInt(pruned, 10))
+	}
+	return h
+}
+
+// logPrunedPeer notes a peer that advertised a prune height
+func logPrunedPeer(peer string, h http.Header) {
+	if pruned := h.Get(pruneHeightHeader); pruned != "" {
+		log.Printf("✂️ Peer %s is pruned up to height %s", peer, pruned)
+	}
+}
+
+// addTimeSample feeds a peer's handshake Date into network-adjusted time,
+// keyed by the peer's IP so its port does not make it count twice
+func (n *Node) addTimeSample(ws *websocket.Conn, h http.Header) {
+	t, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		return
+	}
+	host, _, err := net.SplitHostPort(ws.RemoteAddr().String())
+	if err != nil {
+		return
+	}
+	n.Blockchain.TimeSource().AddTimeSample(host, t)
+}
+
+// readLimit bounds a single peer message. Blocks travel in their binary
+// encoding, so MaxBlockSize plus slack is enough; anything bigger cannot be
+// a valid block and drops the peer.
+func (n *Node) readLimit() int64 {
+	return int64(n.Blockchain.Params().MaxBlockSize) + 64*1024
+}
+
 // Listen for messages from a peer
 func (n *Node) ListenPeer(ws *websocket.Conn) {
+	ws.SetReadLimit(n.readLimit())
 	for {
-		var incoming block.Block
-		if err := ws.ReadJSON(&incoming); err != nil {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
 			log.Println("Error reading block from peer:", err)
 			n.Mutex.Lock()
 			delete(n.Peers, ws.RemoteAddr().String())
 			n.Mutex.Unlock()
 			return
 		}
-		// Validate and add block
-		n.Blockchain.MineBlock(incoming.Transactions)
+		incoming, err := block.Deserialize(msg)
+		if err != nil {
+			log.Println("Rejected block from peer:", err)
+			continue
+		}
+		// Validate and add block. Moving the tip cancels any local mining
+		// still working on the old one.
+		if err := n.Blockchain.AcceptBlock(incoming); err != nil {
+			log.Println("Rejected block from peer:", err)
+			continue
+		}
 		log.Println("✅ Received block from peer and added to chain")
 	}
 }
 
 // Broadcast a block to all peers
 func (n *Node) BroadcastBlock(b *block.Block) {
+	msg := b.Serialize()
 	n.Mutex.Lock()
 	defer n.Mutex.Unlock()
 	for peer, ws := range n.Peers {
-		if err := ws.WriteJSON(b); err != nil {
+		if err := ws.WriteMessage(websocket.BinaryMessage, msg); err != nil {
 			log.Println("Failed to send block to peer", peer, err)
 		}
 	}
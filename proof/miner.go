@@ -0,0 +1,128 @@
+package proof
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// How many nonces a worker tries between checks for cancellation
+const cancelCheckInterval = 1 << 12
+
+// ErrNonceSpaceExhausted is returned when every nonce failed and the block
+// cannot be changed to open up a new nonce space
+var ErrNonceSpaceExhausted = errors.New("proof: nonce space exhausted")
+
+// NonceSpaceRoller is implemented by blocks that can change a committed
+// field (timestamp, extra-nonce) once every nonce has been tried
+type NonceSpaceRoller interface {
+	RollNonceSpace()
+}
+
+// MiningStats reports the work done by one call to Run
+type MiningStats struct {
+	Workers  int
+	Hashes   uint64        // nonces tried across all workers
+	Elapsed  time.Duration // wall time spent searching
+	HashRate float64       // hashes per second
+	Rolls    int           // times the nonce space was rolled
+}
+
+func (s MiningStats) String() string {
+	return fmt.Sprintf("%d hashes in %s (%.0f H/s, %d workers, %d rolls)",
+		s.Hashes, s.Elapsed.Round(time.Millisecond), s.HashRate, s.Workers, s.Rolls)
+}
+
+// Run searches for a nonce whose hash is below the target. The nonce space
+// is split across Workers goroutines and all of them stop as soon as one
+// finds a solution, or with ctx.Err() when ctx is cancelled. Once every
+// nonce up to MaxNonce has failed the block's nonce space is rolled.
+func (pow *ProofOfWork) Run(ctx context.Context) (int64, []byte, error) {
+	workers := pow.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	start := time.Now()
+	var hashes uint64
+	pow.Stats = MiningStats{Workers: workers}
+	defer func() {
+		pow.Stats.Hashes = hashes
+		pow.Stats.Elapsed = time.Since(start)
+		if secs := pow.Stats.Elapsed.Seconds(); secs > 0 {
+			pow.Stats.HashRate = float64(hashes) / secs
+		}
+	}()
+
+	for {
+		nonce, hash, found := pow.search(ctx, workers, &hashes)
+		if found {
+			return nonce, hash, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		roller, ok := pow.Block.(NonceSpaceRoller)
+		if !ok {
+			return 0, nil, ErrNonceSpaceExhausted
+		}
+		roller.RollNonceSpace()
+		pow.Stats.Rolls++
+	}
+}
+
+// search runs one pass over [0, MaxNonce]; worker i tries i, i+n, i+2n...
+func (pow *ProofOfWork) search(ctx context.Context, workers int, hashes *uint64) (int64, []byte, bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type solution struct {
+		nonce int64
+		hash  []byte
+	}
+	found := make(chan solution, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(first int64) {
+			defer wg.Done()
+
+			var hashInt big.Int
+			var tried uint64
+			defer func() { atomic.AddUint64(hashes, tried) }()
+
+			header := pow.Block.SerializeHeader() // private copy per worker
+			for nonce := first; nonce <= pow.MaxNonce && nonce >= 0; nonce += int64(workers) {
+				if tried%cancelCheckInterval == 0 && ctx.Err() != nil {
+					return
+				}
+
+				hash := sha256.Sum256(pow.prepareData(header, nonce))
+				tried++
+				hashInt.SetBytes(hash[:])
+
+				if hashInt.Cmp(pow.Target) == -1 {
+					found <- solution{nonce, hash[:]}
+					cancel()
+					return
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+
+	select {
+	case s := <-found:
+		return s.nonce, s.hash, true
+	default:
+		return 0, nil, false
+	}
+}
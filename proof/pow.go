@@ -1,82 +1,73 @@
 package proof
 
 import (
-	"bytes"
 	"crypto/sha256"
-	"fmt"
+	"encoding/binary"
 	"math"
 	"math/big"
 )
 
-// Target bits define the difficulty. More bits = harder.
-const targetBits = 16
-
 // 👇 This interface removes the need to import the block package
 type BlockData interface {
 	PrevHash() []byte
-	DataBytes() []byte
 	TimestampUnix() int64
 	NonceValue() int64
+	BitsValue() uint32
+
+	// SerializeHeader returns the canonical binary header, which ends with
+	// the nonce as 8 little-endian bytes
+	SerializeHeader() []byte
 }
 
 type ProofOfWork struct {
 	Block  BlockData
 	Target *big.Int
-}
 
-// Prepare data for hashing
-func (pow *ProofOfWork) prepareData(nonce int64) []byte {
-	return bytes.Join(
-		[][]byte{
-			pow.Block.PrevHash(),
-			pow.Block.DataBytes(),
-			[]byte(fmt.Sprintf("%d", pow.Block.TimestampUnix())),
-			[]byte(fmt.Sprintf("%d", targetBits)),
-			[]byte(fmt.Sprintf("%d", nonce)),
-		},
-		[]byte{},
-	)
-}
+	// Workers is the number of goroutines Run searches with (0 = one per CPU)
+	Workers int
 
-// Main mining loop
-func (pow *ProofOfWork) Run() (int64, []byte) {
-	var hashInt big.Int
-	var hash [32]byte
-	var nonce int64 = 0
-
-	fmt.Println("⛏️ Mining a new block...")
+	// MaxNonce is the last nonce tried before the nonce space is rolled
+	MaxNonce int64
 
-	for nonce < math.MaxInt64 {
-		data := pow.prepareData(nonce)
-		hash = sha256.Sum256(data)
-		hashInt.SetBytes(hash[:])
+	// Stats describes the last call to Run
+	Stats MiningStats
+}
 
-		if hashInt.Cmp(pow.Target) == -1 {
-			break // found!
-		} else {
-			nonce++
-		}
-	}
+// nonceSize is the length of the nonce at the end of a serialized header
+const nonceSize = 8
 
-	fmt.Printf("✅ Mined! Nonce: %d\n", nonce)
-	fmt.Printf("🔑 Hash: %x\n", hash[:])
+// Prepare data for hashing: the header with nonce patched into its last bytes
+func (pow *ProofOfWork) prepareData(header []byte, nonce int64) []byte {
+	binary.LittleEndian.PutUint64(header[len(header)-nonceSize:], uint64(nonce))
+	return header
+}
 
-	return nonce, hash[:]
+// Hash recomputes the block hash for the nonce stored in the block
+func (pow *ProofOfWork) Hash() []byte {
+	hash := sha256.Sum256(pow.Block.SerializeHeader())
+	return hash[:]
 }
 
 // Validates PoW
 func (pow *ProofOfWork) Validate() bool {
 	var hashInt big.Int
-	data := pow.prepareData(pow.Block.NonceValue())
-	hash := sha256.Sum256(data)
+	hash := sha256.Sum256(pow.Block.SerializeHeader())
 	hashInt.SetBytes(hash[:])
 
 	return hashInt.Cmp(pow.Target) == -1
 }
 
-// Constructor
-func NewProofOfWork(b BlockData) *ProofOfWork {
+// Target returns the hash ceiling for a difficulty of bits. More bits = harder.
+func Target(bits uint32) *big.Int {
+	if bits > 255 {
+		bits = 255
+	}
 	target := big.NewInt(1)
-	target.Lsh(target, uint(256-targetBits))
-	return &ProofOfWork{b, target}
+	target.Lsh(target, uint(256-bits))
+	return target
+}
+
+// Constructor: the difficulty is read from the block itself
+func NewProofOfWork(b BlockData) *ProofOfWork {
+	return &ProofOfWork{Block: b, Target: Target(b.BitsValue()), MaxNonce: math.MaxInt64}
 }
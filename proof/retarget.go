@@ -0,0 +1,29 @@
+package proof
+
+// RetargetBits moves bits towards the difficulty that would have produced
+// targetTimespan instead of actualTimespan. Each bit doubles the work, so a
+// window that ran 2x too fast or too slow shifts the difficulty by one bit,
+// capped at two bits (a factor of 4) per retarget.
+func RetargetBits(bits uint32, actualTimespan, targetTimespan int64) uint32 {
+	if actualTimespan < 1 {
+		actualTimespan = 1
+	}
+
+	delta := 0
+	switch {
+	case actualTimespan*4 <= targetTimespan:
+		delta = 2
+	case actualTimespan*2 <= targetTimespan:
+		delta = 1
+	case actualTimespan >= targetTimespan*4:
+		delta = -2
+	case actualTimespan >= targetTimespan*2:
+		delta = -1
+	}
+
+	next := int64(bits) + int64(delta)
+	if next < 0 {
+		next = 0
+	}
+	return uint32(next)
+}
@@ -0,0 +1,32 @@
+package proof
+
+import "testing"
+
+func TestRetargetBits(t *testing.T) {
+	tests := []struct {
+		bits           uint32
+		actual, target int64
+		want           uint32
+	}{
+		{10, 100, 100, 10},
+		{10, 51, 100, 10},
+		{10, 50, 100, 11},
+		{10, 26, 100, 11},
+		{10, 25, 100, 12},
+		{10, 1, 100, 12},  // capped at +2
+		{10, 0, 100, 12},  // clock went backwards
+		{10, -5, 100, 12}, // ditto
+		{10, 199, 100, 10},
+		{10, 200, 100, 9},
+		{10, 399, 100, 9},
+		{10, 400, 100, 8},
+		{10, 100000, 100, 8}, // capped at -2
+		{1, 400, 100, 0},
+		{0, 400, 100, 0}, // never below zero
+	}
+	for _, tt := range tests {
+		if got := RetargetBits(tt.bits, tt.actual, tt.target); got != tt.want {
+			t.Errorf("RetargetBits(%d, %d, %d) = %d, want %d", tt.bits, tt.actual, tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Shubham0699/go-mini-blockchain/block"
+)
+
+// ---------------- GET /block?height=N or /block?hash=xxx ----------------
+func (s *Server) handleGetBlock(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var b *block.Block
+	var err error
+	switch {
+	case q.Get("height") != "":
+		height, parseErr := strconv.ParseInt(q.Get("height"), 10, 64)
+		if parseErr != nil {
+			http.Error(w, "Invalid height parameter", http.StatusBadRequest)
+			return
+		}
+		b, err = s.Blockchain.GetBlockByHeight(height)
+	case q.Get("hash") != "":
+		hash, decodeErr := hex.DecodeString(q.Get("hash"))
+		if decodeErr != nil {
+			http.Error(w, "Invalid hash parameter", http.StatusBadRequest)
+			return
+		}
+		b, err = s.Blockchain.GetBlockByHash(hash)
+	default:
+		http.Error(w, "Missing height or hash parameter", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, block.ErrBlockPruned) {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b)
+}
+
+// ---------------- GET /bestheight ----------------
+func (s *Server) handleGetBestHeight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	res := map[string]interface{}{
+		"height":      s.Blockchain.BestHeight(),
+		"hash":        hex.EncodeToString(s.Blockchain.Tip()),
+		"pruned":      s.Blockchain.IsPruned(),
+		"pruneheight": s.Blockchain.PruneHeight(),
+	}
+	if pending, ok := s.Blockchain.PendingTxOutSet(); ok {
+		res["snapshotheight"] = pending.Height
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// ---------------- GET /balance?address=xxx ----------------
+func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	pubKeyHash, err := hex.DecodeString(address)
+	if err != nil || len(pubKeyHash) == 0 {
+		http.Error(w, "Missing or invalid address parameter", http.StatusBadRequest)
+		return
+	}
+
+	outputs := s.Blockchain.FindUTXO(pubKeyHash)
+	balance := 0
+	for _, out := range outputs {
+		balance += out.Value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"address": address,
+		"balance": balance,
+		"outputs": len(outputs),
+	})
+}
+
+// ---------------- GET /transaction?id=xxx ----------------
+func (s *Server) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := hex.DecodeString(r.URL.Query().Get("id"))
+	if err != nil || len(id) == 0 {
+		http.Error(w, "Missing or invalid id parameter", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.Blockchain.FindTransaction(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"txid":          hex.EncodeToString(res.Transaction.ID),
+		"blockhash":     hex.EncodeToString(res.BlockHash),
+		"blockheight":   res.BlockHeight,
+		"position":      res.Position,
+		"confirmations": res.Confirmations,
+		"transaction":   res.Transaction,
+	})
+}
+
+// maxHistoryPage caps the count parameter of /address
+const maxHistoryPage = 100
+
+// ---------------- GET /address?address=xxx&skip=N&count=N ----------------
+func (s *Server) handleGetAddressHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	pubKeyHash, err := hex.DecodeString(q.Get("address"))
+	if err != nil || len(pubKeyHash) == 0 {
+		http.Error(w, "Missing or invalid address parameter", http.StatusBadRequest)
+		return
+	}
+
+	skip, count := 0, 20
+	if v := q.Get("skip"); v != "" {
+		if skip, err = strconv.Atoi(v); err != nil || skip < 0 {
+			http.Error(w, "Invalid skip parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("count"); v != "" {
+		if count, err = strconv.Atoi(v); err != nil || count < 1 || count > maxHistoryPage {
+			http.Error(w, fmt.Sprintf("count must be between 1 and %d", maxHistoryPage), http.StatusBadRequest)
+			return
+		}
+	}
+
+	history, total, err := s.Blockchain.AddressHistory(pubKeyHash, skip, count)
+	if err == block.ErrNoAddrIndex {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type entry struct {
+		TxID        string `json:"txid"`
+		BlockHeight int64  `json:"blockheight"`
+		Position    int    `json:"position"`
+		Funding     bool   `json:"funding"`
+		Spending    bool   `json:"spending"`
+	}
+	entries := make([]entry, 0, len(history))
+	for _, h := range history {
+		entries = append(entries, entry{hex.EncodeToString(h.TxID), h.BlockHeight, h.Position, h.Funding, h.Spending})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"address":      q.Get("address"),
+		"total":        total,
+		"skip":         skip,
+		"transactions": entries,
+	})
+}
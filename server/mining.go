@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Shubham0699/go-mini-blockchain/block"
+	"github.com/Shubham0699/go-mini-blockchain/tx"
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
+)
+
+// maxTemplates bounds the templates kept for one tip; the oldest is
+// dropped first
+const maxTemplates = 64
+
+// Templates handed out on the current tip. Those on any other tip can no
+// longer be submitted and are dropped as soon as the tip is seen to move.
+type templateCache struct {
+	mu        sync.Mutex
+	tip       []byte
+	templates map[string]*block.BlockTemplate // keyed by hex merkle root
+	order     []string                        // merkle roots, oldest first
+}
+
+// sync drops every template when tip is not the one they were made on
+func (c *templateCache) sync(tip []byte) {
+	if !bytes.Equal(c.tip, tip) {
+		c.tip = tip
+		c.templates = make(map[string]*block.BlockTemplate)
+		c.order = nil
+	}
+}
+
+// add keeps tmpl if it builds on tip, the current chain tip
+func (c *templateCache) add(tip []byte, tmpl *block.BlockTemplate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sync(tip)
+	if !bytes.Equal(tmpl.Block.PrevBlockHash, tip) {
+		return
+	}
+	root := hex.EncodeToString(tmpl.Block.MerkleRoot)
+	if _, ok := c.templates[root]; !ok {
+		if len(c.order) == maxTemplates {
+			delete(c.templates, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, root)
+	}
+	c.templates[root] = tmpl
+}
+
+// get returns the template with the hex merkle root root, if it is still
+// on tip
+func (c *templateCache) get(tip []byte, root string) *block.BlockTemplate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sync(tip)
+	return c.templates[root]
+}
+
+type templateResponse struct {
+	Version           uint32            `json:"version"`
+	PreviousBlockHash string            `json:"previousblockhash"`
+	MerkleRoot        string            `json:"merkleroot"`
+	CurTime           int64             `json:"curtime"`
+	MinTime           int64             `json:"mintime"`
+	Bits              uint32            `json:"bits"`
+	Target            string            `json:"target"`
+	Height            int64             `json:"height"`
+	CoinbaseValue     int               `json:"coinbasevalue"`
+	Header            string            `json:"header"`
+	Transactions      []*tx.Transaction `json:"transactions"`
+}
+
+// ---------------- GET /getblocktemplate?address=xxx ----------------
+func (s *Server) handleGetBlockTemplate(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if !wallet.IsAddress(address) {
+		http.Error(w, fmt.Sprintf("Missing or invalid address parameter, expected %d hex characters", 2*wallet.AddressSize), http.StatusBadRequest)
+		return
+	}
+
+	// no mempool yet, so the template only carries the coinbase
+	tmpl, err := s.Blockchain.NewBlockTemplate(address, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	b := tmpl.Block
+	s.templates.add(s.Blockchain.Tip(), tmpl)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templateResponse{
+		Version:           b.Version,
+		PreviousBlockHash: hex.EncodeToString(b.PrevBlockHash),
+		MerkleRoot:        hex.EncodeToString(b.MerkleRoot),
+		CurTime:           b.Timestamp,
+		MinTime:           tmpl.MinTime,
+		Bits:              b.Bits,
+		Target:            fmt.Sprintf("%064x", tmpl.Target),
+		Height:            tmpl.Height,
+		CoinbaseValue:     tmpl.CoinbaseValue,
+		Header:            hex.EncodeToString(b.SerializeHeader()),
+		Transactions:      b.Transactions,
+	})
+}
+
+// ---------------- POST /submitblock ----------------
+// Body: {"header": "<hex serialized header>", "nonce": <optional override>}
+func (s *Server) handleSubmitBlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Header string `json:"header"`
+		Nonce  *int64 `json:"nonce"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Header == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	raw, err := hex.DecodeString(body.Header)
+	if err != nil {
+		http.Error(w, "Header is not valid hex", http.StatusBadRequest)
+		return
+	}
+	header, err := block.DeserializeHeader(raw)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if body.Nonce != nil {
+		header.Nonce = *body.Nonce
+	}
+
+	tmpl := s.templates.get(s.Blockchain.Tip(), hex.EncodeToString(header.MerkleRoot[:]))
+	if tmpl == nil {
+		http.Error(w, "Unknown or stale block template", http.StatusNotFound)
+		return
+	}
+
+	solved, err := tmpl.Solve(header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.Blockchain.AcceptBlock(solved); err != nil {
+		http.Error(w, "Block rejected: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.announce(solved)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Block accepted",
+		"hash":    hex.EncodeToString(solved.Hash),
+	})
+}
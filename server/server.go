@@ -4,22 +4,42 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/Shubham0699/go-mini-blockchain/block"
 )
 
 type Server struct {
 	Blockchain *block.Blockchain
+	templates  templateCache
+	broadcast  func(*block.Block)
 }
 
-func NewServer(bc *block.Blockchain) *Server {
-	return &Server{Blockchain: bc}
+// NewServer serves bc. Blocks mined or submitted through the API are
+// passed to broadcast, e.g. a p2p node's BroadcastBlock; nil keeps them local.
+func NewServer(bc *block.Blockchain, broadcast func(*block.Block)) *Server {
+	return &Server{Blockchain: bc, broadcast: broadcast}
+}
+
+// announce hands a block the chain accepted to the broadcast callback
+func (s *Server) announce(b *block.Block) {
+	if s.broadcast != nil {
+		s.broadcast(b)
+	}
 }
 
 func (s *Server) Start(port string) {
 	http.HandleFunc("/chain", s.handleGetChain)
 	http.HandleFunc("/addblock", s.handleAddBlockQuery)  // GET way
 	http.HandleFunc("/addblockjson", s.handleAddBlockPost) // POST way
+	http.HandleFunc("/getblocktemplate", s.handleGetBlockTemplate)
+	http.HandleFunc("/submitblock", s.handleSubmitBlock)
+	http.HandleFunc("/supply", s.handleGetSupply)
+	http.HandleFunc("/block", s.handleGetBlock)
+	http.HandleFunc("/bestheight", s.handleGetBestHeight)
+	http.HandleFunc("/transaction", s.handleGetTransaction)
+	http.HandleFunc("/address", s.handleGetAddressHistory)
+	http.HandleFunc("/balance", s.handleGetBalance)
 
 	fmt.Println("🚀 Server running on port", port)
 	err := http.ListenAndServe(":"+port, nil)
@@ -42,7 +62,12 @@ func (s *Server) handleAddBlockQuery(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing data parameter", http.StatusBadRequest)
 		return
 	}
-	s.Blockchain.AddBlock(data)
+	mined, err := s.Blockchain.AddBlockContext(r.Context(), data)
+	if err != nil {
+		writeMiningError(w, err)
+		return
+	}
+	s.announce(mined)
 	fmt.Fprintf(w, "✅ Block added with data: %s", data)
 }
 
@@ -63,7 +88,12 @@ func (s *Server) handleAddBlockPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.Blockchain.AddBlock(body.Data)
+	mined, err := s.Blockchain.AddBlockContext(r.Context(), body.Data)
+	if err != nil {
+		writeMiningError(w, err)
+		return
+	}
+	s.announce(mined)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -71,3 +101,47 @@ func (s *Server) handleAddBlockPost(w http.ResponseWriter, r *http.Request) {
 		"data":    body.Data,
 	})
 }
+
+// ---------------- GET /supply[?height=N] ----------------
+func (s *Server) handleGetSupply(w http.ResponseWriter, r *http.Request) {
+	params := s.Blockchain.Params()
+	w.Header().Set("Content-Type", "application/json")
+
+	// with a height, report the schedule at that height instead of the tip
+	if v := r.URL.Query().Get("height"); v != "" {
+		height, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || height < 0 {
+			http.Error(w, "Invalid height parameter, expected a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]int64{
+			"height":          height,
+			"subsidy":         params.BlockSubsidy(height),
+			"scheduledsupply": params.TotalSupply(height),
+			"maxsupply":       params.MaxSupply,
+			"halvinginterval": params.SubsidyHalvingInterval,
+		})
+		return
+	}
+
+	height := s.Blockchain.BestHeight()
+	json.NewEncoder(w).Encode(map[string]int64{
+		"height":          height,
+		"nextsubsidy":     params.BlockSubsidy(height + 1),
+		"scheduledsupply": params.TotalSupply(height),
+		"issuedsupply":    s.Blockchain.IssuedSupply(),
+		"maxsupply":       params.MaxSupply,
+		"halvinginterval": params.SubsidyHalvingInterval,
+	})
+}
+
+// writeMiningError reports why a mining request did not produce a block.
+// Mining is cancelled when the client goes away or when another block
+// (e.g. one from a peer) becomes the tip first.
+func writeMiningError(w http.ResponseWriter, err error) {
+	if err == block.ErrStaleTip {
+		http.Error(w, "Chain tip changed while mining, please retry", http.StatusConflict)
+		return
+	}
+	http.Error(w, "Mining cancelled: "+err.Error(), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,152 @@
+package tx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/Shubham0699/go-mini-blockchain/wire"
+)
+
+// EncodingVersion is the version of the transaction format below. Decoding
+// rejects any other version.
+//
+// A transaction is laid out as (see package wire for the field types):
+//
+//	uint32   version (1)
+//	bytes    ID
+//	uint32   input count, then each input:
+//	  bytes    Txid (empty for coinbase)
+//	  int32    Vout (-1 for coinbase)
+//	  bytes    Signature (the height and extra-nonce for coinbase)
+//	  bytes    PubKey
+//	uint32   output count, then each output:
+//	  int64    Value
+//	  bytes    PubKeyHash
+//
+// The ID is SHA-256 over the same layout with the ID field left out.
+const EncodingVersion = 1
+
+// smallest encoded input and output, for wire.Reader.Count
+const (
+	minInputSize  = 4 + 4 + 4 + 4
+	minOutputSize = 8 + 4
+)
+
+// Encode writes the input to w. It panics if Vout does not fit an int32;
+// block validation rejects such inputs before they are encoded.
+func (in *TXInput) Encode(w *wire.Writer) {
+	if in.Vout < math.MinInt32 || in.Vout > math.MaxInt32 {
+		log.Panicf("input spends output %d, which does not fit an int32", in.Vout)
+	}
+	w.PutBytes(in.Txid)
+	w.PutInt32(int32(in.Vout))
+	w.PutBytes(in.Signature)
+	w.PutBytes(in.PubKey)
+}
+
+// Decode reads an input written by Encode; failures are left in r
+func (in *TXInput) Decode(r *wire.Reader) {
+	in.Txid = r.Bytes()
+	in.Vout = int(r.Int32())
+	in.Signature = r.Bytes()
+	in.PubKey = r.Bytes()
+}
+
+// Encode writes the output to w
+func (out *TXOutput) Encode(w *wire.Writer) {
+	w.PutInt64(int64(out.Value))
+	w.PutBytes(out.PubKeyHash)
+}
+
+// Decode reads an output written by Encode; failures are left in r
+func (out *TXOutput) Decode(r *wire.Reader) {
+	out.Value = int(r.Int64())
+	out.PubKeyHash = r.Bytes()
+}
+
+// Encode writes the transaction to w
+func (tx *Transaction) Encode(w *wire.Writer) {
+	tx.encode(w, true)
+}
+
+func (tx *Transaction) encode(w *wire.Writer, withID bool) {
+	w.PutUint32(EncodingVersion)
+	if withID {
+		w.PutBytes(tx.ID)
+	}
+	w.PutUint32(uint32(len(tx.Vin)))
+	for i := range tx.Vin {
+		tx.Vin[i].Encode(w)
+	}
+	w.PutUint32(uint32(len(tx.Vout)))
+	for i := range tx.Vout {
+		tx.Vout[i].Encode(w)
+	}
+}
+
+// Decode reads a transaction written by Encode; failures are left in r
+func (tx *Transaction) Decode(r *wire.Reader) {
+	if v := r.Uint32(); r.Err() == nil && v != EncodingVersion {
+		r.Fail(fmt.Errorf("unsupported transaction encoding version %d", v))
+		return
+	}
+	tx.ID = r.Bytes()
+
+	n := r.Count(minInputSize)
+	tx.Vin = nil
+	for i := 0; i < n && r.Err() == nil; i++ {
+		var in TXInput
+		in.Decode(r)
+		tx.Vin = append(tx.Vin, in)
+	}
+	n = r.Count(minOutputSize)
+	tx.Vout = nil
+	for i := 0; i < n && r.Err() == nil; i++ {
+		var out TXOutput
+		out.Decode(r)
+		tx.Vout = append(tx.Vout, out)
+	}
+}
+
+// Serialize returns the binary encoding of the transaction
+func (tx *Transaction) Serialize() []byte {
+	var w wire.Writer
+	tx.Encode(&w)
+	return w.Bytes()
+}
+
+// DeserializeTransaction decodes a transaction produced by Serialize
+func DeserializeTransaction(d []byte) (*Transaction, error) {
+	var tx Transaction
+	r := wire.NewReader(d)
+	tx.Decode(r)
+	if err := r.Done(); err != nil {
+		return nil, fmt.Errorf("decoding transaction: %w", err)
+	}
+	return &tx, nil
+}
+
+// Hex returns the serialized transaction as hex
+func (tx *Transaction) Hex() string {
+	return hex.EncodeToString(tx.Serialize())
+}
+
+// TransactionFromHex decodes a transaction from the output of Hex
+func TransactionFromHex(s string) (*Transaction, error) {
+	d, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decoding transaction: %w", err)
+	}
+	return DeserializeTransaction(d)
+}
+
+// Hash is SHA-256 over the serialized transaction without its ID
+func (tx *Transaction) Hash() []byte {
+	var w wire.Writer
+	tx.encode(&w, false)
+	h := sha256.Sum256(w.Bytes())
+	return h[:]
+}
@@ -0,0 +1,110 @@
+package tx
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// goldenTx and its encoding pin the layout documented on EncodingVersion;
+// the bytes and ID were produced independently of this package
+func goldenTx() *Transaction {
+	t := &Transaction{
+		Vin:  []TXInput{{Txid: []byte{1, 2}, Vout: 3, Signature: []byte{4}, PubKey: []byte{5, 6}}},
+		Vout: []TXOutput{{Value: 50, PubKeyHash: []byte{8, 9}}},
+	}
+	t.ID, _ = hex.DecodeString(goldenTxID)
+	return t
+}
+
+const (
+	goldenTxID  = "306e79f9f0f2a713f7971eb6acc0586dda6876e460b7f468948921c42efc822b"
+	goldenTxHex = "01000000" + // encoding version
+		"20000000" + goldenTxID +
+		"01000000" + // inputs
+		"020000000102" + "03000000" + "0100000004" + "020000000506" +
+		"01000000" + // outputs
+		"3200000000000000" + "020000000809"
+)
+
+func TestTransactionSerializeGolden(t *testing.T) {
+	tx := goldenTx()
+	if got := tx.Hex(); got != goldenTxHex {
+		t.Errorf("serialized transaction\n got %s\nwant %s", got, goldenTxHex)
+	}
+	if id := hex.EncodeToString(tx.Hash()); id != goldenTxID {
+		t.Errorf("transaction hash %s, want %s", id, goldenTxID)
+	}
+}
+
+func TestTransactionRoundTrip(t *testing.T) {
+	want := goldenTx()
+	got, err := TransactionFromHex(want.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+
+	coinbase := NewCoinbaseTX("00112233445566778899aabbccddeeff00112233", 50, 7, 3)
+	got, err = DeserializeTransaction(coinbase.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Hex() != coinbase.Hex() || !got.IsCoinbase() {
+		t.Errorf("coinbase changed in a round trip: %+v", got)
+	}
+	if h, err := got.CoinbaseHeight(); err != nil || h != 7 || got.CoinbaseExtraNonce() != 3 {
+		t.Errorf("decoded coinbase commits to height %d (%v), extra-nonce %d", h, err, got.CoinbaseExtraNonce())
+	}
+}
+
+func TestDeserializeTransactionRejectsMalformed(t *testing.T) {
+	raw, _ := hex.DecodeString(goldenTxHex)
+	for n := 0; n < len(raw); n++ {
+		if _, err := DeserializeTransaction(raw[:n]); err == nil {
+			t.Errorf("truncated to %d bytes: no error", n)
+		}
+	}
+
+	if _, err := DeserializeTransaction(append(raw[:len(raw):len(raw)], 0)); err == nil {
+		t.Error("trailing byte: no error")
+	}
+
+	bad := append([]byte(nil), raw...)
+	bad[0] = 2
+	if _, err := DeserializeTransaction(bad); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("unsupported version: got %v", err)
+	}
+
+	// an input count far beyond what the remaining bytes can hold
+	huge, _ := hex.DecodeString("01000000" + "00000000" + "ffffffff")
+	if _, err := DeserializeTransaction(huge); err == nil {
+		t.Error("oversized input count: no error")
+	}
+	// a byte string longer than the input
+	long, _ := hex.DecodeString("01000000" + "ffffff7f")
+	if _, err := DeserializeTransaction(long); err == nil {
+		t.Error("oversized ID length: no error")
+	}
+}
+
+func TestEncodePanicsOnOutputIndexOutsideInt32(t *testing.T) {
+	tooBig := math.MaxInt32
+	tooBig++
+	for _, vout := range []int{tooBig, -tooBig - 1} {
+		tx := goldenTx()
+		tx.Vin[0].Vout = vout
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Vout %d: encoded without a panic", vout)
+				}
+			}()
+			tx.Serialize()
+		}()
+	}
+}
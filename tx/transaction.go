@@ -1,14 +1,16 @@
 package tx
 
 import (
-	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
-	"encoding/gob"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"math/big"
+
+	"github.com/Shubham0699/go-mini-blockchain/wallet"
 )
 
 // TXInput represents a transaction input
@@ -44,31 +46,73 @@ type Transaction struct {
 	Vout []TXOutput
 }
 
-func (tx *Transaction) Hash() []byte {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	_ = enc.Encode(tx.Vin)
-	_ = enc.Encode(tx.Vout)
-	h := sha256.Sum256(buf.Bytes())
-	return h[:]
-}
-
 func (tx *Transaction) SetID() { tx.ID = tx.Hash() }
 
 func (tx *Transaction) IsCoinbase() bool {
 	return len(tx.Vin) == 1 && len(tx.Vin[0].Txid) == 0 && tx.Vin[0].Vout == -1
 }
 
-// NewCoinbaseTX creates a coinbase (mining reward) transaction
-func NewCoinbaseTX(to string, reward int) *Transaction {
+// coinbaseScriptSize is the length of the coinbase input script: the
+// block height followed by the extra-nonce, both little-endian uint64
+const coinbaseScriptSize = 16
+
+// ErrNoCoinbaseHeight is returned for coinbases that do not commit to a height
+var ErrNoCoinbaseHeight = errors.New("coinbase does not commit to a block height")
+
+// SigOpCount is the number of signatures checked to validate the transaction
+func (tx *Transaction) SigOpCount() int {
+	if tx.IsCoinbase() {
+		return 0
+	}
+	return len(tx.Vin)
+}
+
+// NewCoinbaseTX creates a coinbase (mining reward) transaction for the block
+// at height. The coinbase input carries the height and extraNonce in place
+// of a signature, so no two coinbases share an ID.
+func NewCoinbaseTX(to string, reward int, height int64, extraNonce uint64) *Transaction {
 	tx := &Transaction{
-		Vin:  []TXInput{{Txid: []byte{}, Vout: -1, Signature: nil, PubKey: nil}},
+		Vin:  []TXInput{{Txid: []byte{}, Vout: -1, Signature: coinbaseScript(height, extraNonce), PubKey: nil}},
 		Vout: []TXOutput{NewTXOutput(reward, to)},
 	}
 	tx.SetID()
 	return tx
 }
 
+func coinbaseScript(height int64, extraNonce uint64) []byte {
+	script := make([]byte, coinbaseScriptSize)
+	binary.LittleEndian.PutUint64(script[0:8], uint64(height))
+	binary.LittleEndian.PutUint64(script[8:16], extraNonce)
+	return script
+}
+
+// CoinbaseHeight returns the block height a coinbase commits to
+func (tx *Transaction) CoinbaseHeight() (int64, error) {
+	if !tx.IsCoinbase() || len(tx.Vin[0].Signature) < coinbaseScriptSize {
+		return 0, ErrNoCoinbaseHeight
+	}
+	return int64(binary.LittleEndian.Uint64(tx.Vin[0].Signature[0:8])), nil
+}
+
+// CoinbaseExtraNonce returns the extra-nonce of a coinbase (0 if it has none)
+func (tx *Transaction) CoinbaseExtraNonce() uint64 {
+	if !tx.IsCoinbase() || len(tx.Vin[0].Signature) < coinbaseScriptSize {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(tx.Vin[0].Signature[8:16])
+}
+
+// WithCoinbaseScript returns a copy of the coinbase committing to height
+// and extraNonce, with its ID recomputed. The receiver is left untouched.
+func (tx *Transaction) WithCoinbaseScript(height int64, extraNonce uint64) *Transaction {
+	cb := &Transaction{
+		Vin:  []TXInput{{Txid: []byte{}, Vout: -1, Signature: coinbaseScript(height, extraNonce), PubKey: nil}},
+		Vout: append([]TXOutput(nil), tx.Vout...),
+	}
+	cb.SetID()
+	return cb
+}
+
 // Sign signs each input of the transaction with the provided private key.
 // prevOutMap maps "txid||vout" (hex encoded) to the referenced TXOutput.
 func (tx *Transaction) Sign(priv *ecdsa.PrivateKey, prevOutMap map[string]TXOutput) {
@@ -91,11 +135,12 @@ func (tx *Transaction) Sign(priv *ecdsa.PrivateKey, prevOutMap map[string]TXOutp
 			// In production you'd return an error; for now panic to keep behavior consistent with the rest of the project
 			panic(err)
 		}
-		signature := append(r.Bytes(), s.Bytes()...)
+		// r, s and the key halves are padded so Verify can split them
+		signature := append(wallet.PadTo32(r.Bytes()), wallet.PadTo32(s.Bytes())...)
 
 		// write signature + pubkey back to original tx
 		tx.Vin[inIdx].Signature = signature
-		tx.Vin[inIdx].PubKey = append(priv.PublicKey.X.Bytes(), priv.PublicKey.Y.Bytes()...)
+		tx.Vin[inIdx].PubKey = wallet.PubKeyBytes(&priv.PublicKey)
 	}
 }
 
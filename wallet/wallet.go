@@ -6,8 +6,14 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"math/big"
 )
 
+// ErrInvalidPrivateKey is returned for a private scalar that is zero or not
+// below the order of the curve
+var ErrInvalidPrivateKey = errors.New("private key is out of range")
+
 type Wallet struct {
 	Private *ecdsa.PrivateKey
 	PubKey  []byte // uncompressed: X||Y
@@ -18,13 +24,74 @@ func NewWallet() (*Wallet, error) {
 	if err != nil {
 		return nil, err
 	}
-	pub := append(priv.PublicKey.X.Bytes(), priv.PublicKey.Y.Bytes()...)
-	return &Wallet{Private: priv, PubKey: pub}, nil
+	return fromPrivateKey(priv), nil
+}
+
+// NewWalletFromHex restores a wallet from the hex private key returned by
+// PrivateKeyHex
+func NewWalletFromHex(s string) (*Wallet, error) {
+	d, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	priv := new(ecdsa.PrivateKey)
+	priv.Curve = elliptic.P256()
+	priv.D = new(big.Int).SetBytes(d)
+	if priv.D.Sign() == 0 || priv.D.Cmp(priv.Curve.Params().N) >= 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	priv.PublicKey.X, priv.PublicKey.Y = priv.Curve.ScalarBaseMult(d)
+	return fromPrivateKey(priv), nil
+}
+
+func fromPrivateKey(priv *ecdsa.PrivateKey) *Wallet {
+	return &Wallet{Private: priv, PubKey: PubKeyBytes(&priv.PublicKey)}
+}
+
+// PubKeyBytes encodes a public key as X||Y with both padded to 32 bytes,
+// so the key splits cleanly in half. Transactions, seals and addresses all
+// use this form.
+func PubKeyBytes(pub *ecdsa.PublicKey) []byte {
+	return append(PadTo32(pub.X.Bytes()), PadTo32(pub.Y.Bytes())...)
+}
+
+// PadTo32 left-pads a big-endian number to 32 bytes
+func PadTo32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+// PrivateKeyHex exports the private scalar so the wallet can be restored
+func (w *Wallet) PrivateKeyHex() string {
+	return hex.EncodeToString(w.Private.D.Bytes())
 }
 
 // Very simple address: hex( first 20 bytes of SHA256(pubkey) )
 // (We can upgrade to RIPEMD160+Base58Check later without touching call sites.)
 func (w *Wallet) Address() string {
-	h := sha256.Sum256(w.PubKey)
-	return hex.EncodeToString(h[:20])
+	return AddressFromPubKey(w.PubKey)
+}
+
+// AddressSize is the length of a decoded address in bytes
+const AddressSize = 20
+
+// PubKeyHash returns the raw address of an X||Y public key, the first
+// AddressSize bytes of its SHA-256. Outputs are locked to this hash.
+func PubKeyHash(pubKey []byte) []byte {
+	h := sha256.Sum256(pubKey)
+	return h[:AddressSize]
+}
+
+// AddressFromPubKey derives the address of an X||Y public key
+func AddressFromPubKey(pubKey []byte) string {
+	return hex.EncodeToString(PubKeyHash(pubKey))
+}
+
+// IsAddress reports whether s is a well-formed address: AddressSize bytes
+// in hex
+func IsAddress(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == AddressSize
 }
@@ -0,0 +1,179 @@
+// Package wire holds the primitives of the binary format blocks and
+// transactions are stored and sent in. Integers are fixed-size and
+// little-endian, like the block header. Byte strings and lists are prefixed
+// with their length as a uint32, so every field can be skipped or checked
+// without knowing what follows.
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrShortData is returned when the input ends inside a field
+var ErrShortData = errors.New("wire: unexpected end of data")
+
+// Writer appends fields to a buffer
+type Writer struct {
+	buf bytes.Buffer
+}
+
+// PutUint8 writes a single byte
+func (w *Writer) PutUint8(v uint8) {
+	w.buf.WriteByte(v)
+}
+
+// PutBool writes 1 for true and 0 for false
+func (w *Writer) PutBool(v bool) {
+	if v {
+		w.PutUint8(1)
+	} else {
+		w.PutUint8(0)
+	}
+}
+
+// PutUint32 writes v in 4 bytes
+func (w *Writer) PutUint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.buf.Write(b[:])
+}
+
+// PutInt32 writes v in 4 bytes, two's complement
+func (w *Writer) PutInt32(v int32) {
+	w.PutUint32(uint32(v))
+}
+
+// PutInt64 writes v in 8 bytes, two's complement
+func (w *Writer) PutInt64(v int64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(v))
+	w.buf.Write(b[:])
+}
+
+// PutBytes writes the length of b followed by b
+func (w *Writer) PutBytes(b []byte) {
+	w.PutUint32(uint32(len(b)))
+	w.buf.Write(b)
+}
+
+// Bytes returns everything written so far
+func (w *Writer) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// Reader consumes fields from a byte slice. The first failure sticks: every
+// later read returns a zero value, and Err reports what went wrong.
+type Reader struct {
+	d   []byte
+	err error
+}
+
+// NewReader reads fields from d
+func NewReader(d []byte) *Reader {
+	return &Reader{d: d}
+}
+
+// Err returns the first error the reader met
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Fail records err unless an earlier error is already recorded
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Done returns the first error, or an error if input is left over
+func (r *Reader) Done() error {
+	if r.err == nil && len(r.d) > 0 {
+		r.err = fmt.Errorf("wire: %d unexpected trailing bytes", len(r.d))
+	}
+	return r.err
+}
+
+func (r *Reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.d) {
+		r.err = ErrShortData
+		return nil
+	}
+	b := r.d[:n]
+	r.d = r.d[n:]
+	return b
+}
+
+// Uint8 reads a single byte
+func (r *Reader) Uint8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+// Bool reads a byte that must be 0 or 1
+func (r *Reader) Bool() bool {
+	switch v := r.Uint8(); v {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		r.Fail(fmt.Errorf("wire: invalid bool byte %d", v))
+		return false
+	}
+}
+
+// Uint32 reads 4 bytes
+func (r *Reader) Uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// Int32 reads 4 bytes, two's complement
+func (r *Reader) Int32() int32 {
+	return int32(r.Uint32())
+}
+
+// Int64 reads 8 bytes, two's complement
+func (r *Reader) Int64() int64 {
+	if b := r.next(8); b != nil {
+		return int64(binary.LittleEndian.Uint64(b))
+	}
+	return 0
+}
+
+// Bytes reads a length-prefixed byte string into a new slice. Empty strings
+// come back as nil.
+func (r *Reader) Bytes() []byte {
+	n := r.Uint32()
+	if uint64(n) > uint64(len(r.d)) {
+		r.Fail(ErrShortData)
+		return nil
+	}
+	b := r.next(int(n))
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+// Count reads a list length. Every element takes at least minSize bytes,
+// so a count the remaining input cannot hold is rejected before anything
+// is allocated for it.
+func (r *Reader) Count(minSize int) int {
+	n := r.Uint32()
+	if r.err == nil && uint64(n)*uint64(minSize) > uint64(len(r.d)) {
+		r.Fail(fmt.Errorf("wire: count %d does not fit in %d bytes", n, len(r.d)))
+		return 0
+	}
+	return int(n)
+}